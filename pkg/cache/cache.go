@@ -1,35 +1,336 @@
 package cache
 
 import (
+	"container/list"
 	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/muesli/cache2go"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
 )
 
-// Cache embeds in memory cache2go.
+// Category groups the cache entries sharing a time to live.
+type Category string
+
+const (
+	// Totals are the trips of medallions over the whole history.
+	Totals Category = "totals"
+	// PickUpDates are the trips of medallions on a single pick up or drop off date, daily counts included.
+	PickUpDates Category = "pickupdates"
+	// Ranges are the trips of medallions in a date range.
+	Ranges Category = "ranges"
+	// Rankings are the medallions with the most trips.
+	Rankings Category = "rankings"
+	// Drivers are the trips of drivers.
+	Drivers Category = "drivers"
+	// Metrics are the trip figures of medallions.
+	Metrics Category = "metrics"
+)
+
+// Store provides methods to get, set and clear cached trip figures.
+// Cache keeps the entries in memory, Redis shares them between replicas.
+type Store interface {
+	Get(ctx context.Context, key string) (int, error)
+	Set(ctx context.Context, category Category, key string, val int)
+	GetRanking(ctx context.Context, key string) ([]output.RankedResult, error)
+	SetRanking(ctx context.Context, key string, val []output.RankedResult)
+	GetDriver(ctx context.Context, key string) (output.DriverResult, error)
+	SetDriver(ctx context.Context, key string, val output.DriverResult)
+	GetMetrics(ctx context.Context, key string) (output.Metrics, error)
+	SetMetrics(ctx context.Context, key string, val output.Metrics)
+	Clear(ctx context.Context) int
+	Invalidate(ctx context.Context, sel Selector) int
+	Stats(ctx context.Context) output.CacheStats
+	Entries(ctx context.Context, prefix string, limit int) []output.CacheEntry
+}
+
+var (
+	_ Store = (*Cache)(nil)
+	_ Store = (*Redis)(nil)
+)
+
+// Config sets the time to live of each category, bounds the number of entries and scopes keys to the namespace.
+// Entries of a category without a time to live never expire, zero max entries does not bound the cache.
+type Config struct {
+	TTLs       map[Category]time.Duration
+	MaxEntries int
+	Namespace  Namespace
+}
+
+// Cache is an in memory cache of trip figures.
+// Entries expire after the time to live of their category and the least recently used entry
+// is evicted when adding an entry beyond max entries.
+// Hits, misses, evictions and the approximate bytes of the entries are counted for Stats.
 type Cache struct {
-	cache *cache2go.CacheTable
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+	ttls       map[Category]time.Duration
+	maxEntries int
+	prefix     string
+	now        func() time.Time
+	hits       int64
+	misses     int64
+	evictions  int64
+	bytes      int64
+}
+
+// entry is the value of a key set at created, the zero expiry never expires.
+type entry struct {
+	key     string
+	val     interface{}
+	created time.Time
+	expires time.Time
+	size    int64
 }
 
+// entryOverhead approximates the bytes of the map slot, list element and entry of each key.
+const entryOverhead = 160
+
 // New creates a new instance of cache.
-func New(cache *cache2go.CacheTable) *Cache { return &Cache{cache: cache} }
+func New(cfg Config) *Cache {
+	return &Cache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		ttls:       cfg.TTLs,
+		maxEntries: cfg.MaxEntries,
+		prefix:     cfg.Namespace.String(),
+		now:        time.Now,
+	}
+}
 
 // Get retrieves cache entries.
+// Keys without an entry return failure.ErrCacheMiss.
 func (c *Cache) Get(ctx context.Context, key string) (int, error) {
-	res, err := c.cache.Value(key)
-	if err != nil {
-		return 0, err
+	val, ok := c.value(key).(int)
+	if !ok {
+		return 0, failure.ErrCacheMiss
 	}
-	return res.Data().(int), nil
+	return val, nil
 }
 
 // Set adds new cache entries.
-func (c *Cache) Set(ctx context.Context, key string, val int) {
-	c.cache.Add(key, 0, val)
+func (c *Cache) Set(ctx context.Context, category Category, key string, val int) {
+	c.add(category, key, val)
+}
+
+// GetRanking retrieves cached medallion rankings.
+func (c *Cache) GetRanking(ctx context.Context, key string) ([]output.RankedResult, error) {
+	val, ok := c.value(key).([]output.RankedResult)
+	if !ok {
+		return nil, failure.ErrCacheMiss
+	}
+	return val, nil
+}
+
+// SetRanking adds medallion rankings.
+func (c *Cache) SetRanking(ctx context.Context, key string, val []output.RankedResult) {
+	c.add(Rankings, key, val)
+}
+
+// GetDriver retrieves cached driver trips.
+func (c *Cache) GetDriver(ctx context.Context, key string) (output.DriverResult, error) {
+	val, ok := c.value(key).(output.DriverResult)
+	if !ok {
+		return output.DriverResult{}, failure.ErrCacheMiss
+	}
+	return val, nil
+}
+
+// SetDriver adds driver trips.
+func (c *Cache) SetDriver(ctx context.Context, key string, val output.DriverResult) {
+	c.add(Drivers, key, val)
+}
+
+// GetMetrics retrieves cached medallion metrics.
+func (c *Cache) GetMetrics(ctx context.Context, key string) (output.Metrics, error) {
+	val, ok := c.value(key).(output.Metrics)
+	if !ok {
+		return output.Metrics{}, failure.ErrCacheMiss
+	}
+	return val, nil
+}
+
+// SetMetrics adds medallion metrics.
+func (c *Cache) SetMetrics(ctx context.Context, key string, val output.Metrics) {
+	c.add(Metrics, key, val)
+}
+
+// Clear flush cache entries and returns how many live entries were evicted.
+func (c *Cache) Clear(ctx context.Context) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var evicted int
+	for _, el := range c.entries {
+		if !el.Value.(*entry).expired(now) {
+			evicted++
+		}
+	}
+	c.evictions += int64(evicted)
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+	return evicted
+}
+
+// Invalidate evicts the entries of the namespace sel selects and returns how many live entries were evicted.
+func (c *Cache) Invalidate(ctx context.Context, sel Selector) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var evicted int
+	for key, el := range c.entries {
+		if !strings.HasPrefix(key, c.prefix) || !sel.matches(key[len(c.prefix):]) {
+			continue
+		}
+		if c.remove(el, now) {
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Stats reports the live entries, hits, misses, evictions and approximate bytes of the cache.
+func (c *Cache) Stats(ctx context.Context) output.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	stats := output.CacheStats{Backend: "memory", Hits: c.hits, Misses: c.misses, Evictions: c.evictions, Bytes: c.bytes}
+	for _, el := range c.entries {
+		if !el.Value.(*entry).expired(now) {
+			stats.Entries++
+		}
+	}
+	stats.HitRatio = hitRatio(stats.Hits, stats.Misses)
+	return stats
+}
 
+// Entries lists at most limit live entries of the namespace with keys starting with prefix, ordered by key.
+// Listing entries neither counts as hits nor changes which entry is the least recently used.
+func (c *Cache) Entries(ctx context.Context, prefix string, limit int) []output.CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var keys []string
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, c.prefix+prefix) && !el.Value.(*entry).expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	entries := make([]output.CacheEntry, 0, len(keys))
+	for _, key := range keys {
+		e := c.entries[key].Value.(*entry)
+		res := output.CacheEntry{Key: strings.TrimPrefix(key, c.prefix), Value: e.val, Age: seconds(now.Sub(e.created))}
+		if !e.expires.IsZero() {
+			res.TTL = seconds(e.expires.Sub(now))
+		}
+		entries = append(entries, res)
+	}
+	return entries
+}
+
+// value returns the value of key in the namespace, nil when missing or expired.
+// Reading an entry makes it the most recently used.
+func (c *Cache) value(key string) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[c.prefix+key]
+	if !ok {
+		c.misses++
+		return nil
+	}
+	e := el.Value.(*entry)
+	if now := c.now(); e.expired(now) {
+		c.remove(el, now)
+		c.misses++
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	c.hits++
+	return e.val
+}
+
+// add sets the value of key in the namespace as the most recently used entry, evicting the least recently used entries beyond max entries.
+func (c *Cache) add(category Category, key string, val interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key = c.prefix + key
+	now := c.now()
+	var expires time.Time
+	if ttl := c.ttls[category]; ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	size := sizeOf(key, val)
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		c.bytes += size - e.size
+		e.val, e.created, e.expires, e.size = val, now, expires, size
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.bytes += size
+	c.entries[key] = c.lru.PushFront(&entry{key: key, val: val, created: now, expires: expires, size: size})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back(), now)
+	}
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// remove drops the entry of el and reports whether it was live at now.
+// Only live entries count as evictions, expired entries are merely reclaimed.
+func (c *Cache) remove(el *list.Element, now time.Time) bool {
+	e := el.Value.(*entry)
+	c.lru.Remove(el)
+	delete(c.entries, e.key)
+	c.bytes -= e.size
+	if e.expired(now) {
+		return false
+	}
+	c.evictions++
+	return true
+}
+
+// sizeOf approximates the bytes held by the entry of key and val.
+func sizeOf(key string, val interface{}) int64 {
+	size := int64(entryOverhead + len(key))
+	switch v := val.(type) {
+	case int:
+		size += 8
+	case []output.RankedResult:
+		for _, r := range v {
+			size += 40 + int64(len(r.Medallion))
+		}
+	case output.DriverResult:
+		size += 48 + int64(len(v.HackLicense))
+		for _, med := range v.Medallions {
+			size += 16 + int64(len(med))
+		}
+	case output.Metrics:
+		size += 128 + int64(len(v.Medallion))
+	}
+	return size
+}
+
+// hitRatio is the share of hits among lookups, zero without lookups.
+func hitRatio(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
 }
 
-// Clear flush cache entries.
-func (c *Cache) Clear(ctx context.Context) {
-	c.cache.Flush()
+func seconds(d time.Duration) *float64 {
+	s := d.Seconds()
+	return &s
 }
@@ -1 +1,414 @@
 package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+// clock is a fake clock advanced by tests.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) Now() time.Time { return c.t }
+
+func (c *clock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestCache(cfg Config) (*Cache, *clock) {
+	clk := &clock{t: time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)}
+	c := New(cfg)
+	c.now = clk.Now
+	return c, clk
+}
+
+func TestCache_TTL(t *testing.T) {
+	t.Parallel()
+	ttls := map[Category]time.Duration{Totals: time.Hour, PickUpDates: 24 * time.Hour}
+	type args struct {
+		Category Category
+		Elapsed  time.Duration
+	}
+	type want struct {
+		Error error
+		Val   int
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{
+			Name: "Live entry",
+			Args: args{Category: Totals, Elapsed: time.Hour - time.Second},
+			Want: want{Val: 5},
+		},
+		{
+			Name: "Entry expires at its time to live",
+			Args: args{Category: Totals, Elapsed: time.Hour},
+			Want: want{Error: failure.ErrCacheMiss},
+		},
+		{
+			Name: "Category time to live",
+			Args: args{Category: PickUpDates, Elapsed: 2 * time.Hour},
+			Want: want{Val: 5},
+		},
+		{
+			Name: "Category without time to live never expires",
+			Args: args{Category: Ranges, Elapsed: 24 * 365 * time.Hour},
+			Want: want{Val: 5},
+		},
+	}
+	for _, tc := range testTable {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			c, clk := newTestCache(Config{TTLs: ttls})
+			c.Set(context.Background(), tc.Args.Category, "med1", 5)
+			clk.Advance(tc.Args.Elapsed)
+			val, err := c.Get(context.Background(), "med1")
+			assert.Equal(t, tc.Want.Error, err)
+			assert.Equal(t, tc.Want.Val, val)
+		})
+	}
+}
+
+func TestCache_SetRefreshesTTL(t *testing.T) {
+	t.Parallel()
+	c, clk := newTestCache(Config{TTLs: map[Category]time.Duration{Totals: time.Hour}})
+	c.Set(context.Background(), Totals, "med1", 5)
+	clk.Advance(30 * time.Minute)
+	c.Set(context.Background(), Totals, "med1", 6)
+	clk.Advance(45 * time.Minute)
+	val, err := c.Get(context.Background(), "med1")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, val)
+	clk.Advance(15 * time.Minute)
+	_, err = c.Get(context.Background(), "med1")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+}
+
+func TestCache_LRU(t *testing.T) {
+	t.Parallel()
+	type want struct {
+		Live    []string
+		Evicted []string
+	}
+	testTable := []struct {
+		Name       string
+		MaxEntries int
+		Ops        func(c *Cache)
+		Want       want
+	}{
+		{
+			Name:       "Least recently added entry is evicted",
+			MaxEntries: 2,
+			Ops: func(c *Cache) {
+				c.Set(context.Background(), Totals, "med1", 1)
+				c.Set(context.Background(), Totals, "med2", 2)
+				c.Set(context.Background(), Totals, "med3", 3)
+			},
+			Want: want{Live: []string{"med2", "med3"}, Evicted: []string{"med1"}},
+		},
+		{
+			Name:       "Get makes entry the most recently used",
+			MaxEntries: 2,
+			Ops: func(c *Cache) {
+				c.Set(context.Background(), Totals, "med1", 1)
+				c.Set(context.Background(), Totals, "med2", 2)
+				_, _ = c.Get(context.Background(), "med1")
+				c.Set(context.Background(), Totals, "med3", 3)
+			},
+			Want: want{Live: []string{"med1", "med3"}, Evicted: []string{"med2"}},
+		},
+		{
+			Name:       "Overwriting an entry does not evict",
+			MaxEntries: 2,
+			Ops: func(c *Cache) {
+				c.Set(context.Background(), Totals, "med1", 1)
+				c.Set(context.Background(), Totals, "med2", 2)
+				c.Set(context.Background(), Totals, "med1", 3)
+			},
+			Want: want{Live: []string{"med1", "med2"}},
+		},
+		{
+			Name: "Zero max entries does not bound",
+			Ops: func(c *Cache) {
+				c.Set(context.Background(), Totals, "med1", 1)
+				c.Set(context.Background(), Totals, "med2", 2)
+				c.Set(context.Background(), Totals, "med3", 3)
+			},
+			Want: want{Live: []string{"med1", "med2", "med3"}},
+		},
+	}
+	for _, tc := range testTable {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := newTestCache(Config{MaxEntries: tc.MaxEntries})
+			tc.Ops(c)
+			for _, key := range tc.Want.Evicted {
+				_, err := c.Get(context.Background(), key)
+				assert.Equal(t, failure.ErrCacheMiss, err, key)
+			}
+			for _, key := range tc.Want.Live {
+				_, err := c.Get(context.Background(), key)
+				assert.NoError(t, err, key)
+			}
+		})
+	}
+}
+
+func TestCache_Categories(t *testing.T) {
+	t.Parallel()
+	c, clk := newTestCache(Config{TTLs: map[Category]time.Duration{Rankings: time.Hour, Drivers: 2 * time.Hour, Metrics: 3 * time.Hour}})
+	ranking := []output.RankedResult{{Rank: 1, Medallion: "med1", Trips: 5}}
+	driver := output.DriverResult{HackLicense: "hack1", Trips: 5}
+	metrics := output.Metrics{Medallion: "med1", Trips: 5}
+	c.SetRanking(context.Background(), "top", ranking)
+	c.SetDriver(context.Background(), "hack1", driver)
+	c.SetMetrics(context.Background(), "med1", metrics)
+
+	_, err := c.Get(context.Background(), "top")
+	assert.Equal(t, failure.ErrCacheMiss, err, "rankings are not counts")
+
+	clk.Advance(time.Hour)
+	_, err = c.GetRanking(context.Background(), "top")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+	gotDriver, err := c.GetDriver(context.Background(), "hack1")
+	assert.NoError(t, err)
+	assert.Equal(t, driver, gotDriver)
+
+	clk.Advance(time.Hour)
+	_, err = c.GetDriver(context.Background(), "hack1")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+	gotMetrics, err := c.GetMetrics(context.Background(), "med1")
+	assert.NoError(t, err)
+	assert.Equal(t, metrics, gotMetrics)
+
+	c.Clear(context.Background())
+	_, err = c.GetMetrics(context.Background(), "med1")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+}
+
+// selectorTests lists the keys of entries a selector evicts, keys are set by setSelectorKeys.
+var selectorTests = []struct {
+	Name    string
+	Sel     Selector
+	Evicted []string
+}{
+	{
+		Name: "Every entry",
+		Sel:  Selector{},
+		Evicted: []string{
+			"total:MED1", "date:MED1:pickup:20130111:UTC", "date:MED1:pickup:20131101:America/New_York", "range:MED1:pickup:20131201:20131231:America/New_York",
+			"metrics:MED1", "metrics:MED1:dropoff:20130101:20130131:UTC", "total:MED2", "ranking:pickup:10:20131201:20131231",
+			"ranking:pickup:10:00010101:00010101", "driver:LIC1",
+		},
+	},
+	{
+		Name:    "Prefix",
+		Sel:     Selector{Prefix: "metrics:MED1"},
+		Evicted: []string{"metrics:MED1", "metrics:MED1:dropoff:20130101:20130131:UTC"},
+	},
+	{
+		Name: "Medallion",
+		Sel:  Selector{Medallion: "MED1"},
+		Evicted: []string{
+			"total:MED1", "date:MED1:pickup:20130111:UTC", "date:MED1:pickup:20131101:America/New_York", "range:MED1:pickup:20131201:20131231:America/New_York",
+			"metrics:MED1", "metrics:MED1:dropoff:20130101:20130131:UTC", "ranking:pickup:10:20131201:20131231", "ranking:pickup:10:00010101:00010101",
+		},
+	},
+	{
+		Name: "Medallion on date",
+		Sel:  Selector{Medallion: "MED1", From: time.Date(2013, 1, 11, 0, 0, 0, 0, time.UTC), To: time.Date(2013, 1, 11, 0, 0, 0, 0, time.UTC)},
+		Evicted: []string{
+			"total:MED1", "date:MED1:pickup:20130111:UTC", "metrics:MED1", "metrics:MED1:dropoff:20130101:20130131:UTC", "ranking:pickup:10:00010101:00010101",
+		},
+	},
+	{
+		Name: "Medallion in date range",
+		Sel:  Selector{Medallion: "MED1", From: time.Date(2013, 11, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)},
+		Evicted: []string{
+			"total:MED1", "date:MED1:pickup:20131101:America/New_York", "range:MED1:pickup:20131201:20131231:America/New_York", "metrics:MED1",
+			"ranking:pickup:10:20131201:20131231", "ranking:pickup:10:00010101:00010101",
+		},
+	},
+	{
+		Name:    "Medallion since date",
+		Sel:     Selector{Medallion: "MED1", From: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Evicted: []string{"total:MED1", "metrics:MED1", "ranking:pickup:10:00010101:00010101"},
+	},
+	{
+		Name:    "Medallion and prefix",
+		Sel:     Selector{Prefix: "total:", Medallion: "MED1"},
+		Evicted: []string{"total:MED1"},
+	},
+	{
+		Name: "Unknown prefix",
+		Sel:  Selector{Prefix: "range:MED*"},
+	},
+}
+
+// selectorKeys are set by setSelectorKeys in the order of the every entry selector test.
+var selectorKeys = selectorTests[0].Evicted
+
+func setSelectorKeys(c Store) {
+	for _, key := range selectorKeys {
+		switch {
+		case strings.HasPrefix(key, "ranking:"):
+			c.SetRanking(context.Background(), key, []output.RankedResult{{Rank: 1, Medallion: "MED1", Trips: 5}})
+		case strings.HasPrefix(key, "driver:"):
+			c.SetDriver(context.Background(), key, output.DriverResult{HackLicense: "LIC1", Trips: 5})
+		case strings.HasPrefix(key, "metrics:"):
+			c.SetMetrics(context.Background(), key, output.Metrics{Medallion: "MED1", Trips: 5})
+		default:
+			c.Set(context.Background(), Totals, key, 5)
+		}
+	}
+}
+
+// liveKeys lists the selector keys still cached in c.
+func liveKeys(c *Cache) []string {
+	var keys []string
+	for _, key := range selectorKeys {
+		if c.value(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	t.Parallel()
+	for _, tc := range selectorTests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := newTestCache(Config{Namespace: Namespace{Version: 1}})
+			setSelectorKeys(c)
+
+			evicted := c.Invalidate(context.Background(), tc.Sel)
+			assert.Equal(t, len(tc.Evicted), evicted, "evicted")
+			assert.ElementsMatch(t, without(selectorKeys, tc.Evicted), liveKeys(c), "live keys")
+		})
+	}
+}
+
+func TestCache_InvalidateExpired(t *testing.T) {
+	t.Parallel()
+	c, clk := newTestCache(Config{TTLs: map[Category]time.Duration{Totals: time.Hour}})
+	c.Set(context.Background(), Totals, "total:MED1", 5)
+	c.Set(context.Background(), Ranges, "range:MED1:pickup:20131201:20131231:America/New_York", 5)
+	clk.Advance(time.Hour)
+	assert.Equal(t, 1, c.Invalidate(context.Background(), Selector{Medallion: "MED1"}), "expired entries are not counted")
+	assert.Equal(t, 0, c.lru.Len())
+	assert.Equal(t, int64(1), c.Stats(context.Background()).Evictions, "evictions match the entries reported evicted")
+
+	c.Set(context.Background(), Totals, "total:MED1", 5)
+	c.Set(context.Background(), Ranges, "range:MED1:pickup:20131201:20131231:America/New_York", 5)
+	clk.Advance(time.Hour)
+	assert.Equal(t, 1, c.Clear(context.Background()), "expired entries are not counted")
+}
+
+// without lists keys missing from evicted.
+func without(keys, evicted []string) []string {
+	var live []string
+	for _, key := range keys {
+		var found bool
+		for _, e := range evicted {
+			found = found || e == key
+		}
+		if !found {
+			live = append(live, key)
+		}
+	}
+	return live
+}
+
+func TestCache_Stats(t *testing.T) {
+	t.Parallel()
+	c, clk := newTestCache(Config{TTLs: map[Category]time.Duration{Totals: time.Hour}, MaxEntries: 2})
+	assert.Equal(t, output.CacheStats{Backend: "memory"}, c.Stats(context.Background()))
+
+	c.Set(context.Background(), Totals, "total:MED1", 5)
+	c.Set(context.Background(), Ranges, "range:MED1:pickup:20131201:20131231:America/New_York", 5)
+	_, _ = c.Get(context.Background(), "total:MED1")
+	_, _ = c.Get(context.Background(), "total:MED1")
+	_, _ = c.Get(context.Background(), "total:MED2")
+	stats := c.Stats(context.Background())
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.InDelta(t, 2.0/3, stats.HitRatio, 1e-9)
+	assert.Equal(t, int64(0), stats.Evictions)
+	assert.Equal(t, sizeOf(c.prefix+"total:MED1", 5)+sizeOf(c.prefix+"range:MED1:pickup:20131201:20131231:America/New_York", 5), stats.Bytes)
+
+	c.Set(context.Background(), Totals, "total:MED3", 5)
+	clk.Advance(time.Hour)
+	_, _ = c.Get(context.Background(), "total:MED1")
+	stats = c.Stats(context.Background())
+	assert.Equal(t, 0, stats.Entries, "least recently used range evicted by the bound, totals expired")
+	assert.Equal(t, int64(1), stats.Evictions, "the expired total read is not an eviction")
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, sizeOf(c.prefix+"total:MED3", 5), stats.Bytes, "expired entries take memory until read or evicted")
+
+	c.Set(context.Background(), Totals, "total:MED4", 5)
+	c.Clear(context.Background())
+	stats = c.Stats(context.Background())
+	assert.Equal(t, 0, stats.Entries)
+	assert.Equal(t, int64(2), stats.Evictions, "expired entries cleared are not evictions")
+	assert.Equal(t, int64(0), stats.Bytes)
+}
+
+func TestCache_Entries(t *testing.T) {
+	t.Parallel()
+	c, clk := newTestCache(Config{TTLs: map[Category]time.Duration{Totals: time.Hour}, MaxEntries: 3})
+	c.Set(context.Background(), Totals, "total:MED2", 7)
+	c.Set(context.Background(), Ranges, "range:MED1:pickup:20131201:20131231:America/New_York", 3)
+	clk.Advance(30 * time.Minute)
+	c.Set(context.Background(), Totals, "total:MED1", 5)
+	clk.Advance(15 * time.Minute)
+
+	age, ttl := 15*60.0, 45*60.0
+	oldAge, oldTTL := 45*60.0, 15*60.0
+	rangeAge, hour := 45*60.0, 3600.0
+	testTable := []struct {
+		Name   string
+		Prefix string
+		Limit  int
+		Want   []output.CacheEntry
+	}{
+		{
+			Name:   "Prefix",
+			Prefix: "total:",
+			Limit:  10,
+			Want:   []output.CacheEntry{{Key: "total:MED1", Value: 5, Age: &age, TTL: &ttl}, {Key: "total:MED2", Value: 7, Age: &oldAge, TTL: &oldTTL}},
+		},
+		{
+			Name:  "Limit",
+			Limit: 1,
+			Want:  []output.CacheEntry{{Key: "range:MED1:pickup:20131201:20131231:America/New_York", Value: 3, Age: &rangeAge}},
+		},
+		{
+			Name:   "Unknown prefix",
+			Prefix: "daily:",
+			Limit:  10,
+			Want:   []output.CacheEntry{},
+		},
+	}
+	for _, tc := range testTable {
+		assert.Equal(t, tc.Want, c.Entries(context.Background(), tc.Prefix, tc.Limit), tc.Name)
+	}
+	assert.Equal(t, output.CacheStats{Backend: "memory", Entries: 3, Bytes: c.bytes}, c.Stats(context.Background()), "listing entries is not a lookup")
+
+	c.Set(context.Background(), Totals, "total:MED3", 1)
+	assert.Equal(t, []output.CacheEntry{{Key: "total:MED1", Value: 5, Age: &age, TTL: &ttl}, {Key: "total:MED3", Value: 1, Age: new(float64), TTL: &hour}},
+		c.Entries(context.Background(), "total:", 10), "listing entries does not refresh the least recently used")
+}
@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+// Kind is the query type prefix of cache keys, keeping the entries of each query apart.
+type Kind string
+
+const (
+	// KindTotal keys the trips of a medallion over the whole history.
+	KindTotal Kind = "total"
+	// KindDate keys the trips of a medallion on a date of a time zone.
+	KindDate Kind = "date"
+	// KindRange keys the trips of a medallion in a date range.
+	KindRange Kind = "range"
+	// KindMetrics keys the trip figures of a medallion over the whole history or a date range.
+	KindMetrics Kind = "metrics"
+	// KindRanking keys the medallions with the most trips in a date range.
+	KindRanking Kind = "ranking"
+	// KindDriver keys the trips of a driver over the whole history.
+	KindDriver Kind = "driver"
+	// KindDriverDate keys the trips of a driver on a date.
+	KindDriverDate Kind = "driverdate"
+)
+
+// keySep separates the segments of keys, medallions, licenses, dates and time zones never contain it.
+const keySep = ":"
+
+// keyDateLayout encodes dates with a fixed width, so that every date has its own key.
+const keyDateLayout = "20060102"
+
+// Namespace scopes keys to a key schema version and optionally to a tenant and a dataset.
+// Bumping the version leaves the entries of previous versions unread, they expire or are evicted in time.
+type Namespace struct {
+	Version int
+	Tenant  string
+	Dataset string
+}
+
+// String is the prefix of keys in the namespace, e.g. tenant=acme:dataset=2013:v1:.
+func (n Namespace) String() string {
+	return n.scope() + "v" + strconv.Itoa(n.Version) + keySep
+}
+
+// scope is the prefix of keys of the tenant and dataset in every version.
+func (n Namespace) scope() string {
+	var scope string
+	if n.Tenant != "" {
+		scope += "tenant=" + n.Tenant + keySep
+	}
+	if n.Dataset != "" {
+		scope += "dataset=" + n.Dataset + keySep
+	}
+	return scope
+}
+
+// TotalKey keys the trips of medallion over the whole history, e.g. total:MED.
+func TotalKey(medallion string) string {
+	return join(KindTotal, medallion)
+}
+
+// DateKey keys the trips of medallion on date per basis in the time zone of date, e.g. date:MED:pickup:20131231:America/New_York.
+func DateKey(medallion string, date time.Time, basis output.Basis) string {
+	return join(KindDate, medallion, string(basis), date.Format(keyDateLayout), date.Location().String())
+}
+
+// RangeKey keys the trips of medallion in the inclusive range per basis in the time zone of from,
+// e.g. range:MED:pickup:20131201:20131231:America/New_York.
+func RangeKey(medallion string, from, to time.Time, basis output.Basis) string {
+	return join(KindRange, medallion, string(basis), from.Format(keyDateLayout), to.Format(keyDateLayout), from.Location().String())
+}
+
+// MetricsKey keys the trip figures of medallion in the inclusive range per basis in the time zone of from,
+// e.g. metrics:MED:pickup:20131201:20131231:America/New_York.
+// Zero from or to keys the figures of the whole history by the medallion alone, e.g. metrics:MED.
+func MetricsKey(medallion string, from, to time.Time, basis output.Basis) string {
+	if from.IsZero() || to.IsZero() {
+		return join(KindMetrics, medallion)
+	}
+	return join(KindMetrics, medallion, string(basis), from.Format(keyDateLayout), to.Format(keyDateLayout), from.Location().String())
+}
+
+// RankingKey keys the limit medallions with the most trips in the inclusive range per basis, e.g. ranking:pickup:10:20131201:20131231.
+func RankingKey(from, to time.Time, basis output.Basis, limit int) string {
+	return join(KindRanking, string(basis), strconv.Itoa(limit), from.Format(keyDateLayout), to.Format(keyDateLayout))
+}
+
+// DriverKey keys the trips of the driver of license over the whole history, e.g. driver:LIC.
+func DriverKey(license string) string {
+	return join(KindDriver, license)
+}
+
+// DriverDateKey keys the trips of the driver of license on date per basis in the time zone of date,
+// e.g. driverdate:LIC:pickup:20131231:America/New_York.
+func DriverDateKey(license string, date time.Time, basis output.Basis) string {
+	return join(KindDriverDate, license, string(basis), date.Format(keyDateLayout), date.Location().String())
+}
+
+// categoryOf is the category setting the time to live of the entries of kind.
+func categoryOf(kind Kind) Category {
+	switch kind {
+	case KindTotal:
+		return Totals
+	case KindDate:
+		return PickUpDates
+	case KindRange:
+		return Ranges
+	case KindMetrics:
+		return Metrics
+	case KindRanking:
+		return Rankings
+	default:
+		return Drivers
+	}
+}
+
+func join(kind Kind, segments ...string) string {
+	return string(kind) + keySep + strings.Join(segments, keySep)
+}
+
+// Selector selects the cache entries to invalidate, the zero selector selects every entry of the namespace.
+// Prefix selects the keys starting with it. Medallion selects the entries counting trips of the medallion,
+// rankings included since any medallion may change them. From and To narrow the entries of the medallion
+// to those counting trips of a date in the inclusive range, zero leaves the range open on that side.
+type Selector struct {
+	Prefix    string
+	Medallion string
+	From, To  time.Time
+}
+
+// matches reports whether the selector selects key, the key without its namespace.
+func (s Selector) matches(key string) bool {
+	if !strings.HasPrefix(key, s.Prefix) {
+		return false
+	}
+	if s.Medallion == "" {
+		return true
+	}
+	k := parseKey(key)
+	switch k.kind {
+	case KindTotal, KindDate, KindRange, KindMetrics:
+		if k.subject != s.Medallion {
+			return false
+		}
+	case KindRanking:
+	default:
+		return false
+	}
+	return s.overlaps(k.from, k.to)
+}
+
+// overlaps reports whether the inclusive range of the selector overlaps the inclusive from and to key dates.
+// Fixed width dates compare as strings, empty key dates count trips of every date.
+func (s Selector) overlaps(from, to string) bool {
+	if !s.To.IsZero() && from != "" && from > s.To.Format(keyDateLayout) {
+		return false
+	}
+	if !s.From.IsZero() && to != "" && to < s.From.Format(keyDateLayout) {
+		return false
+	}
+	return true
+}
+
+// parsedKey is the kind, subject and inclusive dates of a key, empty dates are open.
+type parsedKey struct {
+	kind     Kind
+	subject  string
+	from, to string
+}
+
+// zeroDate is the encoding of the zero time, an open side of a ranking range.
+var zeroDate = time.Time{}.Format(keyDateLayout)
+
+func parseKey(key string) parsedKey {
+	segments := strings.Split(key, keySep)
+	k := parsedKey{kind: Kind(segments[0])}
+	switch k.kind {
+	case KindTotal, KindDriver:
+		k.subject = segment(segments, 1)
+	case KindDate, KindDriverDate:
+		k.subject = segment(segments, 1)
+		k.from, k.to = segment(segments, 3), segment(segments, 3)
+	case KindRange, KindMetrics:
+		k.subject = segment(segments, 1)
+		k.from, k.to = segment(segments, 3), segment(segments, 4)
+	case KindRanking:
+		k.from, k.to = segment(segments, 3), segment(segments, 4)
+	}
+	if k.from == zeroDate {
+		k.from = ""
+	}
+	if k.to == zeroDate {
+		k.to = ""
+	}
+	return k
+}
+
+func segment(segments []string, i int) string {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return ""
+}
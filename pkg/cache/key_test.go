@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err, "Unable to load time zone")
+	jan11 := time.Date(2013, 1, 11, 0, 0, 0, 0, time.UTC)
+	dec31 := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	testTable := []struct {
+		Name string
+		Key  string
+		Want string
+	}{
+		{Name: "Total", Key: TotalKey("med1"), Want: "total:med1"},
+		{Name: "Date", Key: DateKey("med1", jan11, output.PickUp), Want: "date:med1:pickup:20130111:UTC"},
+		{Name: "Date in time zone", Key: DateKey("med1", time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.DropOff), Want: "date:med1:dropoff:20131231:America/New_York"},
+		{Name: "Range", Key: RangeKey("med1", jan11, dec31, output.DropOff), Want: "range:med1:dropoff:20130111:20131231:UTC"},
+		{Name: "Range in time zone", Key: RangeKey("med1", time.Date(2013, 12, 1, 0, 0, 0, 0, newYork), time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp), Want: "range:med1:pickup:20131201:20131231:America/New_York"},
+		{Name: "Metrics of all dates", Key: MetricsKey("med1", time.Time{}, time.Time{}, output.PickUp), Want: "metrics:med1"},
+		{Name: "Metrics", Key: MetricsKey("med1", jan11, dec31, output.PickUp), Want: "metrics:med1:pickup:20130111:20131231:UTC"},
+		{Name: "Metrics in time zone", Key: MetricsKey("med1", time.Date(2013, 12, 1, 0, 0, 0, 0, newYork), time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp), Want: "metrics:med1:pickup:20131201:20131231:America/New_York"},
+		{Name: "Ranking", Key: RankingKey(jan11, dec31, output.PickUp, 10), Want: "ranking:pickup:10:20130111:20131231"},
+		{Name: "Driver", Key: DriverKey("lic1"), Want: "driver:lic1"},
+		{Name: "Driver date", Key: DriverDateKey("lic1", dec31, output.PickUp), Want: "driverdate:lic1:pickup:20131231:UTC"},
+		{Name: "Driver date in time zone", Key: DriverDateKey("lic1", time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp), Want: "driverdate:lic1:pickup:20131231:America/New_York"},
+	}
+	for _, tc := range testTable {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.Want, tc.Key)
+		})
+	}
+}
+
+func TestKeys_FixedWidthDates(t *testing.T) {
+	t.Parallel()
+	jan11 := time.Date(2013, 1, 11, 0, 0, 0, 0, time.UTC)
+	nov1 := time.Date(2013, 11, 1, 0, 0, 0, 0, time.UTC)
+	assert.NotEqual(t, DriverDateKey("lic1", jan11, output.PickUp), DriverDateKey("lic1", nov1, output.PickUp))
+	assert.NotEqual(t, DateKey("med1", jan11, output.PickUp), DateKey("med1", nov1, output.PickUp))
+	assert.NotEqual(t, RangeKey("med1", jan11, nov1, output.PickUp), RangeKey("med1", nov1, jan11, output.PickUp))
+}
+
+func TestNamespace(t *testing.T) {
+	t.Parallel()
+	testTable := []struct {
+		Name      string
+		Namespace Namespace
+		Want      string
+	}{
+		{Name: "Version", Namespace: Namespace{Version: 1}, Want: "v1:"},
+		{Name: "Tenant", Namespace: Namespace{Version: 2, Tenant: "acme"}, Want: "tenant=acme:v2:"},
+		{Name: "Tenant and dataset", Namespace: Namespace{Version: 1, Tenant: "acme", Dataset: "2013"}, Want: "tenant=acme:dataset=2013:v1:"},
+	}
+	for _, tc := range testTable {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.Want, tc.Namespace.String())
+		})
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, `range:MED\*\?\[a\]\\`, escapeGlob(`range:MED*?[a]\`))
+}
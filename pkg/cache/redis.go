@@ -0,0 +1,308 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+// redisPrefix tells the entries of the app apart from other keys in Redis, namespaces follow it.
+const redisPrefix = "cabdata:"
+
+// scanCount is the number of keys scanned per round trip when clearing the cache.
+const scanCount = 1000
+
+// Redis is a cache of trip figures shared by every replica through Redis.
+// Entries expire after the time to live of their category, Redis bounds the memory with its own eviction policy.
+// Redis failures are logged and reported as cache misses, requests then fall back to the DB.
+// Hits, misses and evictions are counted by each replica, Redis expiry and eviction are not counted.
+type Redis struct {
+	// counters come first to be 64 bit aligned for atomic access.
+	hits      int64
+	misses    int64
+	evictions int64
+	client    *redis.Client
+	ttls      map[Category]time.Duration
+	prefix    string
+	scope     string
+	logger    *zap.Logger
+}
+
+// NewRedis creates a new Redis cache, max entries of cfg does not apply.
+func NewRedis(client *redis.Client, cfg Config, logger *zap.Logger) *Redis {
+	return &Redis{
+		client: client,
+		ttls:   cfg.TTLs,
+		prefix: redisPrefix + cfg.Namespace.String(),
+		scope:  redisPrefix + cfg.Namespace.scope(),
+		logger: logger,
+	}
+}
+
+// Get retrieves cache entries.
+// Keys without an entry return failure.ErrCacheMiss.
+func (r *Redis) Get(ctx context.Context, key string) (int, error) {
+	val, err := r.client.Get(r.prefix + key).Int()
+	if err != nil {
+		return 0, r.miss(key, err)
+	}
+	atomic.AddInt64(&r.hits, 1)
+	return val, nil
+}
+
+// Set adds new cache entries.
+func (r *Redis) Set(ctx context.Context, category Category, key string, val int) {
+	r.set(category, key, val)
+}
+
+// GetRanking retrieves cached medallion rankings.
+func (r *Redis) GetRanking(ctx context.Context, key string) ([]output.RankedResult, error) {
+	var val []output.RankedResult
+	if err := r.getJSON(key, &val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// SetRanking adds medallion rankings.
+func (r *Redis) SetRanking(ctx context.Context, key string, val []output.RankedResult) {
+	r.setJSON(Rankings, key, val)
+}
+
+// GetDriver retrieves cached driver trips.
+func (r *Redis) GetDriver(ctx context.Context, key string) (output.DriverResult, error) {
+	var val output.DriverResult
+	if err := r.getJSON(key, &val); err != nil {
+		return output.DriverResult{}, err
+	}
+	return val, nil
+}
+
+// SetDriver adds driver trips.
+func (r *Redis) SetDriver(ctx context.Context, key string, val output.DriverResult) {
+	r.setJSON(Drivers, key, val)
+}
+
+// GetMetrics retrieves cached medallion metrics.
+func (r *Redis) GetMetrics(ctx context.Context, key string) (output.Metrics, error) {
+	var val output.Metrics
+	if err := r.getJSON(key, &val); err != nil {
+		return output.Metrics{}, err
+	}
+	return val, nil
+}
+
+// SetMetrics adds medallion metrics.
+func (r *Redis) SetMetrics(ctx context.Context, key string, val output.Metrics) {
+	r.setJSON(Metrics, key, val)
+}
+
+// Clear flush cache entries of every replica, the entries of previous versions of the namespace included.
+// Keys are scanned rather than flushing the Redis database, which may be shared,
+// entries of other tenants and datasets are left alone.
+// It returns how many entries were evicted.
+func (r *Redis) Clear(ctx context.Context) int {
+	return r.evict(escapeGlob(r.scope)+"v*", func(key string) bool {
+		return versioned(strings.TrimPrefix(key, r.scope))
+	})
+}
+
+// Invalidate evicts the entries of the namespace sel selects for every replica and returns how many entries were evicted.
+func (r *Redis) Invalidate(ctx context.Context, sel Selector) int {
+	return r.evict(escapeGlob(r.prefix+sel.Prefix)+"*", func(key string) bool {
+		return sel.matches(strings.TrimPrefix(key, r.prefix))
+	})
+}
+
+// evict deletes the keys matching pattern that match selects, scanCount keys at a time.
+func (r *Redis) evict(pattern string, match func(key string) bool) int {
+	iter := r.client.Scan(0, pattern, scanCount).Iterator()
+	keys := make([]string, 0, scanCount)
+	var evicted int
+	for iter.Next() {
+		if !match(iter.Val()) {
+			continue
+		}
+		keys = append(keys, iter.Val())
+		if len(keys) == scanCount {
+			evicted += r.del(keys)
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		r.logger.Error("Error scanning cache entries", zap.String("pattern", pattern), zap.Error(err))
+	}
+	return evicted + r.del(keys)
+}
+
+func (r *Redis) del(keys []string) int {
+	if len(keys) == 0 {
+		return 0
+	}
+	n, err := r.client.Del(keys...).Result()
+	if err != nil {
+		r.logger.Error("Error deleting cache entries", zap.Int("keys", len(keys)), zap.Error(err))
+	}
+	atomic.AddInt64(&r.evictions, n)
+	return int(n)
+}
+
+// Stats reports the entries of the namespace with their approximate bytes, and the hits, misses and evictions of the replica.
+func (r *Redis) Stats(ctx context.Context) output.CacheStats {
+	stats := output.CacheStats{
+		Backend:   "redis",
+		Hits:      atomic.LoadInt64(&r.hits),
+		Misses:    atomic.LoadInt64(&r.misses),
+		Evictions: atomic.LoadInt64(&r.evictions),
+	}
+	stats.HitRatio = hitRatio(stats.Hits, stats.Misses)
+	keys := r.keys(escapeGlob(r.prefix) + "*")
+	for start := 0; start < len(keys); start += scanCount {
+		batch := keys[start:min(start+scanCount, len(keys))]
+		pipe := r.client.Pipeline()
+		lens := make([]*redis.IntCmd, 0, len(batch))
+		for _, key := range batch {
+			lens = append(lens, pipe.StrLen(key))
+		}
+		if _, err := pipe.Exec(); err != nil {
+			r.logger.Error("Error sizing cache entries", zap.Error(err))
+		}
+		for i, l := range lens {
+			if l.Val() > 0 {
+				stats.Entries++
+				stats.Bytes += int64(len(batch[i])) + l.Val()
+			}
+		}
+	}
+	return stats
+}
+
+// Entries lists at most limit entries of the namespace with keys starting with prefix, ordered by key.
+// The age of entries is derived from the time to live of their category, null for entries that never expire.
+func (r *Redis) Entries(ctx context.Context, prefix string, limit int) []output.CacheEntry {
+	keys := r.keys(escapeGlob(r.prefix+prefix) + "*")
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	pipe := r.client.Pipeline()
+	vals := make([]*redis.StringCmd, 0, len(keys))
+	ttls := make([]*redis.DurationCmd, 0, len(keys))
+	for _, key := range keys {
+		vals = append(vals, pipe.Get(key))
+		ttls = append(ttls, pipe.PTTL(key))
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+			r.logger.Error("Error listing cache entries", zap.Error(err))
+		}
+	}
+	entries := make([]output.CacheEntry, 0, len(keys))
+	for i, key := range keys {
+		b, err := vals[i].Bytes()
+		if err != nil {
+			continue
+		}
+		var val interface{} = string(b)
+		if json.Valid(b) {
+			val = json.RawMessage(b)
+		}
+		key = strings.TrimPrefix(key, r.prefix)
+		res := output.CacheEntry{Key: key, Value: val}
+		if remaining := ttls[i].Val(); remaining >= 0 {
+			res.TTL = seconds(remaining)
+			if ttl := r.ttls[categoryOf(parseKey(key).kind)]; ttl >= remaining {
+				res.Age = seconds(ttl - remaining)
+			}
+		}
+		entries = append(entries, res)
+	}
+	return entries
+}
+
+// keys scans the keys matching pattern.
+func (r *Redis) keys(pattern string) []string {
+	var keys []string
+	iter := r.client.Scan(0, pattern, scanCount).Iterator()
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		r.logger.Error("Error scanning cache entries", zap.String("pattern", pattern), zap.Error(err))
+	}
+	return keys
+}
+
+// versioned reports whether key follows a key schema version, e.g. v1:total:MED,
+// telling the keys of a scope apart from those of its tenant's datasets.
+func versioned(key string) bool {
+	digits := strings.TrimPrefix(key, "v")
+	end := strings.Index(digits, keySep)
+	if len(digits) == len(key) || end < 1 {
+		return false
+	}
+	for _, c := range digits[:end] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeGlob escapes the special characters of Redis patterns in val.
+func escapeGlob(val string) string {
+	var b strings.Builder
+	for _, c := range val {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (r *Redis) set(category Category, key string, val interface{}) {
+	if err := r.client.Set(r.prefix+key, val, r.ttls[category]).Err(); err != nil {
+		r.logger.Error("Error setting cache entry", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (r *Redis) getJSON(key string, val interface{}) error {
+	b, err := r.client.Get(r.prefix + key).Bytes()
+	if err != nil {
+		return r.miss(key, err)
+	}
+	if err := json.Unmarshal(b, val); err != nil {
+		return r.miss(key, err)
+	}
+	atomic.AddInt64(&r.hits, 1)
+	return nil
+}
+
+func (r *Redis) setJSON(category Category, key string, val interface{}) {
+	b, err := json.Marshal(val)
+	if err != nil {
+		r.logger.Error("Error encoding cache entry", zap.String("key", key), zap.Error(err))
+		return
+	}
+	r.set(category, key, b)
+}
+
+// miss reports err as failure.ErrCacheMiss, logging errors other than a missing key.
+func (r *Redis) miss(key string, err error) error {
+	atomic.AddInt64(&r.misses, 1)
+	if err != redis.Nil {
+		r.logger.Error("Error getting cache entry", zap.String("key", key), zap.Error(err))
+	}
+	return failure.ErrCacheMiss
+}
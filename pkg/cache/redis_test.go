@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+func newTestRedis(t *testing.T, cfg Config) (*Redis, *miniredis.Miniredis) {
+	s, err := miniredis.Run()
+	require.NoError(t, err, "Unable to start redis")
+	t.Cleanup(s.Close)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedis(client, cfg, zap.NewNop()), s
+}
+
+func TestRedis_TTL(t *testing.T) {
+	t.Parallel()
+	ttls := map[Category]time.Duration{Totals: time.Hour, PickUpDates: 24 * time.Hour}
+	type args struct {
+		Category Category
+		Elapsed  time.Duration
+	}
+	type want struct {
+		Error error
+		Val   int
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{
+			Name: "Live entry",
+			Args: args{Category: Totals, Elapsed: time.Hour - time.Second},
+			Want: want{Val: 5},
+		},
+		{
+			Name: "Entry expires at its time to live",
+			Args: args{Category: Totals, Elapsed: time.Hour},
+			Want: want{Error: failure.ErrCacheMiss},
+		},
+		{
+			Name: "Category time to live",
+			Args: args{Category: PickUpDates, Elapsed: 2 * time.Hour},
+			Want: want{Val: 5},
+		},
+		{
+			Name: "Category without time to live never expires",
+			Args: args{Category: Ranges, Elapsed: 24 * 365 * time.Hour},
+			Want: want{Val: 5},
+		},
+	}
+	for _, tc := range testTable {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			c, s := newTestRedis(t, Config{TTLs: ttls})
+			c.Set(context.Background(), tc.Args.Category, "med1", 5)
+			s.FastForward(tc.Args.Elapsed)
+			val, err := c.Get(context.Background(), "med1")
+			assert.Equal(t, tc.Want.Error, err)
+			assert.Equal(t, tc.Want.Val, val)
+		})
+	}
+}
+
+func TestRedis_Categories(t *testing.T) {
+	t.Parallel()
+	c, s := newTestRedis(t, Config{TTLs: map[Category]time.Duration{Rankings: time.Hour}})
+	ranking := []output.RankedResult{{Rank: 1, Medallion: "med1", Trips: 5}}
+	driver := output.DriverResult{HackLicense: "hack1", Trips: 5, Medallions: []string{"med1"}}
+	metrics := output.Metrics{Medallion: "med1", Trips: 5, TotalDistance: 12.5}
+	c.SetRanking(context.Background(), "top", ranking)
+	c.SetDriver(context.Background(), "hack1", driver)
+	c.SetMetrics(context.Background(), "med1", metrics)
+
+	gotRanking, err := c.GetRanking(context.Background(), "top")
+	assert.NoError(t, err)
+	assert.Equal(t, ranking, gotRanking)
+	gotDriver, err := c.GetDriver(context.Background(), "hack1")
+	assert.NoError(t, err)
+	assert.Equal(t, driver, gotDriver)
+	gotMetrics, err := c.GetMetrics(context.Background(), "med1")
+	assert.NoError(t, err)
+	assert.Equal(t, metrics, gotMetrics)
+
+	_, err = c.Get(context.Background(), "top")
+	assert.Equal(t, failure.ErrCacheMiss, err, "rankings are not counts")
+	assert.Equal(t, time.Hour, s.TTL(c.prefix+"top"))
+	assert.Equal(t, time.Duration(0), s.TTL(c.prefix+"hack1"))
+}
+
+func TestRedis_Clear(t *testing.T) {
+	t.Parallel()
+	c, s := newTestRedis(t, Config{})
+	for _, key := range []string{"med1", "med2", "med3"} {
+		c.Set(context.Background(), Totals, key, 1)
+	}
+	require.NoError(t, s.Set("other", "kept"))
+
+	assert.Equal(t, 3, c.Clear(context.Background()))
+	assert.Equal(t, []string{"other"}, s.Keys())
+	_, err := c.Get(context.Background(), "med1")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+}
+
+func TestRedis_Namespace(t *testing.T) {
+	t.Parallel()
+	s, err := miniredis.Run()
+	require.NoError(t, err, "Unable to start redis")
+	defer s.Close()
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+	v1 := NewRedis(client, Config{Namespace: Namespace{Version: 1, Tenant: "acme"}}, zap.NewNop())
+	v2 := NewRedis(client, Config{Namespace: Namespace{Version: 2, Tenant: "acme"}}, zap.NewNop())
+	other := NewRedis(client, Config{Namespace: Namespace{Version: 2, Tenant: "other"}}, zap.NewNop())
+	v1.Set(context.Background(), Totals, "total:med1", 5)
+	other.Set(context.Background(), Totals, "total:med1", 7)
+
+	_, err = v2.Get(context.Background(), "total:med1")
+	assert.Equal(t, failure.ErrCacheMiss, err, "bumped version does not read previous entries")
+	val, err := other.Get(context.Background(), "total:med1")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, val)
+
+	v2.Clear(context.Background())
+	assert.Equal(t, []string{"cabdata:tenant=other:v2:total:med1"}, s.Keys(), "clearing removes every version of the tenant only")
+}
+
+func TestRedis_ClearScope(t *testing.T) {
+	t.Parallel()
+	s, err := miniredis.Run()
+	require.NoError(t, err, "Unable to start redis")
+	defer s.Close()
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+	untenanted := NewRedis(client, Config{Namespace: Namespace{Version: 1}}, zap.NewNop())
+	acme := NewRedis(client, Config{Namespace: Namespace{Version: 1, Tenant: "acme"}}, zap.NewNop())
+	dataset := NewRedis(client, Config{Namespace: Namespace{Version: 1, Tenant: "acme", Dataset: "2013"}}, zap.NewNop())
+	glob := NewRedis(client, Config{Namespace: Namespace{Version: 1, Tenant: "a*"}}, zap.NewNop())
+	for _, c := range []*Redis{untenanted, acme, dataset, glob} {
+		c.Set(context.Background(), Totals, "total:med1", 5)
+	}
+	require.NoError(t, s.Set(redisPrefix+"version", "kept"))
+
+	assert.Equal(t, 1, untenanted.Clear(context.Background()))
+	assert.ElementsMatch(t, []string{
+		"cabdata:version",
+		"cabdata:tenant=acme:v1:total:med1",
+		"cabdata:tenant=acme:dataset=2013:v1:total:med1",
+		"cabdata:tenant=a*:v1:total:med1",
+	}, s.Keys(), "clearing without a tenant leaves tenants and datasets alone")
+
+	assert.Equal(t, 1, glob.Clear(context.Background()))
+	assert.Equal(t, 1, acme.Clear(context.Background()))
+	assert.Equal(t, []string{"cabdata:tenant=acme:dataset=2013:v1:total:med1", "cabdata:version"}, s.Keys(),
+		"clearing a tenant leaves its datasets alone, glob characters match themselves")
+}
+
+func TestRedis_Invalidate(t *testing.T) {
+	t.Parallel()
+	for _, tc := range selectorTests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			c, s := newTestRedis(t, Config{Namespace: Namespace{Version: 2}})
+			setSelectorKeys(c)
+			require.NoError(t, s.Set(redisPrefix+"v1:total:MED1", "7"))
+
+			evicted := c.Invalidate(context.Background(), tc.Sel)
+			assert.Equal(t, len(tc.Evicted), evicted, "evicted")
+			live := []string{redisPrefix + "v1:total:MED1"}
+			for _, key := range without(selectorKeys, tc.Evicted) {
+				live = append(live, c.prefix+key)
+			}
+			assert.ElementsMatch(t, live, s.Keys(), "live keys, entries of other namespaces are left alone")
+		})
+	}
+}
+
+func TestRedis_Stats(t *testing.T) {
+	t.Parallel()
+	c, s := newTestRedis(t, Config{Namespace: Namespace{Version: 1}})
+	require.NoError(t, s.Set(redisPrefix+"v0:total:MED1", "7"))
+	c.Set(context.Background(), Totals, "total:MED1", 5)
+	c.SetDriver(context.Background(), "driver:LIC1", output.DriverResult{HackLicense: "LIC1", Trips: 5})
+	_, _ = c.Get(context.Background(), "total:MED1")
+	_, _ = c.GetDriver(context.Background(), "driver:LIC1")
+	_, _ = c.Get(context.Background(), "total:MED2")
+	c.Invalidate(context.Background(), Selector{Prefix: "total:"})
+
+	stats := c.Stats(context.Background())
+	assert.Equal(t, "redis", stats.Backend)
+	assert.Equal(t, 1, stats.Entries, "entries of the namespace")
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.InDelta(t, 2.0/3, stats.HitRatio, 1e-9)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(len(c.prefix+"driver:LIC1")+len(`{"hackLicense":"LIC1","trips":5,"medallions":null}`)), stats.Bytes)
+}
+
+func TestRedis_Entries(t *testing.T) {
+	t.Parallel()
+	c, s := newTestRedis(t, Config{TTLs: map[Category]time.Duration{Totals: time.Hour}})
+	c.Set(context.Background(), Totals, "total:MED2", 7)
+	c.SetRanking(context.Background(), "ranking:pickup:10:20131201:20131231", []output.RankedResult{{Rank: 1, Medallion: "MED1", Trips: 5}})
+	s.FastForward(15 * time.Minute)
+	c.Set(context.Background(), Totals, "total:MED1", 5)
+
+	age, ttl := 0.0, 3600.0
+	oldAge, oldTTL := 15*60.0, 45*60.0
+	testTable := []struct {
+		Name   string
+		Prefix string
+		Limit  int
+		Want   []output.CacheEntry
+	}{
+		{
+			Name:   "Prefix",
+			Prefix: "total:",
+			Limit:  10,
+			Want: []output.CacheEntry{
+				{Key: "total:MED1", Value: json.RawMessage("5"), Age: &age, TTL: &ttl},
+				{Key: "total:MED2", Value: json.RawMessage("7"), Age: &oldAge, TTL: &oldTTL},
+			},
+		},
+		{
+			Name:  "Limit",
+			Limit: 1,
+			Want:  []output.CacheEntry{{Key: "ranking:pickup:10:20131201:20131231", Value: json.RawMessage(`[{"rank":1,"medallion":"MED1","trips":5}]`)}},
+		},
+		{
+			Name:   "Glob characters are literal",
+			Prefix: "total:*",
+			Limit:  10,
+			Want:   []output.CacheEntry{},
+		},
+	}
+	for _, tc := range testTable {
+		assert.Equal(t, tc.Want, c.Entries(context.Background(), tc.Prefix, tc.Limit), tc.Name)
+	}
+}
+
+func TestRedis_Unavailable(t *testing.T) {
+	t.Parallel()
+	c, s := newTestRedis(t, Config{})
+	c.Set(context.Background(), Totals, "med1", 5)
+	s.Close()
+
+	_, err := c.Get(context.Background(), "med1")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+	_, err = c.GetMetrics(context.Background(), "med1")
+	assert.Equal(t, failure.ErrCacheMiss, err)
+	c.Set(context.Background(), Totals, "med1", 6)
+	assert.Equal(t, 0, c.Clear(context.Background()))
+	assert.Equal(t, 0, c.Invalidate(context.Background(), Selector{Medallion: "med1"}))
+}
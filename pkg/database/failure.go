@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+)
+
+// MySQL error numbers classified as failures.
+const (
+	erConCount         = 1040
+	erServerShutdown   = 1053
+	erTooManyUserConns = 1203
+	erLockWaitTimeout  = 1205
+	erQueryInterrupted = 1317
+	erQueryTimeout     = 3024
+)
+
+// classify marks err with the failure it stands for, so callers can tell failures apart with failure.Is.
+// Errors without a matching failure are returned as is.
+func classify(err error) error {
+	cause := errors.Cause(err)
+	switch cause {
+	case context.Canceled:
+		return failure.Mark(err, failure.ErrCanceled)
+	case context.DeadlineExceeded:
+		return failure.Mark(err, failure.ErrTimeout)
+	case sql.ErrNoRows:
+		return failure.Mark(err, failure.ErrNotFound)
+	case driver.ErrBadConn, sql.ErrConnDone, mysql.ErrInvalidConn:
+		return failure.Mark(err, failure.ErrUnavailable)
+	}
+	switch e := cause.(type) {
+	case *mysql.MySQLError:
+		switch e.Number {
+		case erQueryInterrupted, erQueryTimeout, erLockWaitTimeout:
+			return failure.Mark(err, failure.ErrTimeout)
+		case erConCount, erTooManyUserConns, erServerShutdown:
+			return failure.Mark(err, failure.ErrUnavailable)
+		}
+	case net.Error:
+		return failure.Mark(err, failure.ErrUnavailable)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/nikhil-github/api-cab-data/pkg/database"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+)
+
+func TestQueryFailures(t *testing.T) {
+	type args struct {
+		Err error
+	}
+	type want struct {
+		Failure error
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{Name: "Canceled", Args: args{Err: context.Canceled}, Want: want{Failure: failure.ErrCanceled}},
+		{Name: "Deadline exceeded", Args: args{Err: context.DeadlineExceeded}, Want: want{Failure: failure.ErrTimeout}},
+		{Name: "Max execution time exceeded", Args: args{Err: &mysql.MySQLError{Number: 3024, Message: "Query execution was interrupted, maximum statement execution time exceeded"}}, Want: want{Failure: failure.ErrTimeout}},
+		{Name: "Too many connections", Args: args{Err: &mysql.MySQLError{Number: 1040, Message: "Too many connections"}}, Want: want{Failure: failure.ErrUnavailable}},
+		{Name: "Invalid connection", Args: args{Err: mysql.ErrInvalidConn}, Want: want{Failure: failure.ErrUnavailable}},
+		{Name: "Connection refused", Args: args{Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, Want: want{Failure: failure.ErrUnavailable}},
+		{Name: "Syntax error", Args: args{Err: &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}}},
+	}
+	kinds := []error{failure.ErrCanceled, failure.ErrTimeout, failure.ErrUnavailable, failure.ErrNotFound}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			mock.ExpectQuery(`SELECT`).WillReturnError(tt.Args.Err)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			_, err = dao.TopMedallions(context.Background(), time.Time{}, time.Time{}, "", 10)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to query", "Error")
+			for _, kind := range kinds {
+				assert.Equal(t, kind == tt.Want.Failure, failure.Is(err, kind), kind.Error())
+			}
+		})
+	}
+}
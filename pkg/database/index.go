@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// TripIndex is the name of the composite index on medallion and pick up date time created by CreateTripIndex.
+const TripIndex = "idx_medallion_pickup_datetime"
+
+// indexColumn is a column of an index on the trips table.
+type indexColumn struct {
+	Index  string `db:"index_name"`
+	Column string `db:"column_name"`
+}
+
+// HasTripIndex reports whether an index leading with medallion then pick up date time exists on the trips table, under any name.
+func (q *Queryer) HasTripIndex(ctx context.Context) (bool, error) {
+	var columns []indexColumn
+	query := `
+		SELECT
+			INDEX_NAME AS index_name,
+			COLUMN_NAME AS column_name
+		FROM
+			information_schema.STATISTICS
+		WHERE
+			TABLE_SCHEMA = DATABASE()
+		AND
+			TABLE_NAME = 'cab_trip_data'
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX;
+	`
+	err := q.db.SelectContext(ctx, &columns, q.db.Rebind(query))
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return false, errors.Wrap(classify(err), "failed to query")
+	}
+	leading := map[string][]string{}
+	for _, c := range columns {
+		leading[c.Index] = append(leading[c.Index], c.Column)
+	}
+	for _, cols := range leading {
+		if len(cols) >= 2 && cols[0] == "medallion" && cols[1] == "pickup_datetime" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateTripIndex creates the composite index on medallion and pick up date time.
+// Building the index on the full trips table takes a while and locks the table for writes.
+func (q *Queryer) CreateTripIndex(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, `CREATE INDEX `+TripIndex+` ON cab_trip_data (medallion, pickup_datetime);`)
+	if err != nil {
+		q.logger.Error("sql error on create index", zap.Error(err))
+		return errors.Wrap(classify(err), "failed to create index")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/nikhil-github/api-cab-data/pkg/database"
+)
+
+func TestHasTripIndex(t *testing.T) {
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result bool
+	}
+
+	testTable := []struct {
+		Name   string
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, index found",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"index_name", "column_name"})
+				rows.AddRow("PRIMARY", "id")
+				rows.AddRow(database.TripIndex, "medallion")
+				rows.AddRow(database.TripIndex, "pickup_datetime")
+				selectIndexColumns(m).WillReturnRows(rows)
+			}},
+			Want: want{Result: true},
+		},
+		{
+			Name: "Success, index found under another name with more columns",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"index_name", "column_name"})
+				rows.AddRow("trips_by_cab", "medallion")
+				rows.AddRow("trips_by_cab", "pickup_datetime")
+				rows.AddRow("trips_by_cab", "dropoff_datetime")
+				selectIndexColumns(m).WillReturnRows(rows)
+			}},
+			Want: want{Result: true},
+		},
+		{
+			Name: "Success, index missing",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"index_name", "column_name"})
+				rows.AddRow("medallion", "medallion")
+				rows.AddRow("pickups", "pickup_datetime")
+				rows.AddRow("pickups", "medallion")
+				selectIndexColumns(m).WillReturnRows(rows)
+			}},
+			Want: want{Result: false},
+		},
+		{
+			Name: "Failure, DB error",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectIndexColumns(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.HasTripIndex(context.Background())
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectIndexColumns(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`FROM\s+information_schema.STATISTICS\s+WHERE\s+TABLE_SCHEMA = DATABASE\(\)\s+AND\s+TABLE_NAME = 'cab_trip_data'\s+ORDER BY INDEX_NAME, SEQ_IN_INDEX`)
+}
+
+func TestCreateTripIndex(t *testing.T) {
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error string
+	}
+
+	testTable := []struct {
+		Name   string
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				createIndex(m).WillReturnResult(sqlmock.NewResult(0, 0))
+			}},
+		},
+		{
+			Name: "Failure, DB error",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				createIndex(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to create index: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			err = dao.CreateTripIndex(context.Background())
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+		})
+	}
+}
+
+func createIndex(m sqlmock.Sqlmock) *sqlmock.ExpectedExec {
+	return m.ExpectExec(`CREATE INDEX idx_medallion_pickup_datetime ON cab_trip_data \(medallion, pickup_datetime\)`)
+}
@@ -2,66 +2,105 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
 	"github.com/nikhil-github/api-cab-data/pkg/output"
 )
 
+// chunkSize is the maximum number of medallions bound to a single IN query.
+const chunkSize = 500
+
+// tieColumns order the trips sharing both date times, the trips table has no unique column to tell them apart.
+// Trips tying on every listed column are listed alike, so it does not matter which of them a page skips.
+const tieColumns = "passenger_count, trip_time_in_secs, trip_distance, pickup_longitude, pickup_latitude, dropoff_longitude, dropoff_latitude"
+
 // DBQueryer provides methods for DB interaction.
 type DBQueryer interface {
 	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
-	Select(dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 	Rebind(query string) string
 }
 
 // Queryer provides database query operations.
 type Queryer struct {
 	db     DBQueryer
+	zone   *time.Location
 	logger *zap.Logger
 }
 
 // NewQueryer returns a new instance to query cab trip data.
-func NewQueryer(db DBQueryer, logger *zap.Logger) *Queryer {
-	return &Queryer{db: db, logger: logger}
+// Zone is the time zone trip date times were recorded in.
+func NewQueryer(db DBQueryer, zone *time.Location, logger *zap.Logger) *Queryer {
+	return &Queryer{db: db, zone: zone, logger: logger}
 }
 
-// TripsByMedallionsOnPickUpDate get the count of trips for a cab by medallion and pick up date.
-func (q *Queryer) TripsByMedallionsOnPickUpDate(ctx context.Context, medallion string, pickUpDate time.Time) (output.Result, error) {
-	query := `
+// startOf returns the midnight starting the date, bound as is like recorded date times.
+func startOf(date time.Time) time.Time {
+	d := date.UTC()
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// endOf returns the midnight ending the date, the exclusive end of a half open date range.
+func endOf(date time.Time) time.Time {
+	return startOf(date).AddDate(0, 0, 1)
+}
+
+// recorded returns the wall clock of t in the recording zone.
+// Date times are stored without a zone and bound by the driver in UTC, so the wall clock is bound as UTC.
+func (q *Queryer) recorded(t time.Time) time.Time {
+	w := t.In(q.zone)
+	return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), time.UTC)
+}
+
+// columns returns the date time, latitude and longitude columns trips are counted by.
+// Empty basis counts by pick up.
+func columns(basis output.Basis) (string, string, string) {
+	if basis == output.DropOff {
+		return "dropoff_datetime", "dropoff_latitude", "dropoff_longitude"
+	}
+	return "pickup_datetime", "pickup_latitude", "pickup_longitude"
+}
+
+// TripsByMedallionsOnPickUpDate get the count of trips for cabs by medallion and pick up or drop off date per basis.
+// The date runs from the midnight of pickUpDate to the next midnight in its time zone, so days changing daylight saving time keep their 23 or 25 hours.
+// Medallions without trips on the date are not returned.
+func (q *Queryer) TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis) ([]output.Result, error) {
+	var res []output.Result
+	datetime, _, _ := columns(basis)
+	rawQuery := fmt.Sprintf(`
 		SELECT
-			count(medallion)
-		AS
-			count
+			medallion,
+			count(medallion) AS trips
 		FROM
 			cab_trip_data
 		WHERE	
-			medallion = ?
+			medallion IN (?)
 		AND
-			DATE(pickup_datetime) = DATE(?)
-	`
-	rows, err := q.db.QueryxContext(ctx, query, medallion, pickUpDate)
+			%[1]s >= ?
+		AND
+			%[1]s < ?
+		GROUP BY medallion;
+	`, datetime)
+	query, args, err := sqlx.In(rawQuery, medallions, q.recorded(pickUpDate), q.recorded(pickUpDate.AddDate(0, 0, 1)))
 	if err != nil {
-		q.logger.Error("sql error on query", zap.Error(err))
-		return output.Result{}, errors.Wrap(err, "failed to query")
-	}
-	defer rows.Close()
-	var count int
-	for rows.Next() {
-		if err := rows.Scan(&count); err != nil {
-			q.logger.Error("sql error on scan", zap.Error(err))
-			return output.Result{}, errors.Wrap(err, "failed to query count")
-		}
+		q.logger.Error("sql error on binding medallions", zap.Error(err))
+		return nil, errors.Wrap(err, "failed to build query")
 	}
-	err = rows.Err()
+	err = q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
 	if err != nil {
-		q.logger.Error("sql error on rows", zap.Error(err))
-		return output.Result{}, err
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
 	}
-	return output.Result{Medallion: medallion, Trips: count}, nil
+	return res, nil
 }
 
 // TripsByMedallion get the count of trips for a cab by medallion.
@@ -78,10 +117,446 @@ func (q *Queryer) TripsByMedallion(ctx context.Context, medallions []string) ([]
 		GROUP BY medallion;
 	`
 	query, args, err := sqlx.In(rawQuery, medallions)
-	err = q.db.Select(&res, q.db.Rebind(query), args...)
+	err = q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// MedallionSummaries get every medallion with its total trips and first and last pick up dates, ordered by medallion.
+func (q *Queryer) MedallionSummaries(ctx context.Context) ([]output.MedallionSummary, error) {
+	var res []output.MedallionSummary
+	query := `
+		SELECT
+			medallion,
+			count(medallion) AS trips,
+			DATE_FORMAT(MIN(pickup_datetime), '%Y-%m-%d') AS first_seen,
+			DATE_FORMAT(MAX(pickup_datetime), '%Y-%m-%d') AS last_seen
+		FROM
+			cab_trip_data
+		GROUP BY medallion
+		ORDER BY medallion;
+	`
+	err := q.db.SelectContext(ctx, &res, q.db.Rebind(query))
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// TripsByMedallionsInDateRange get the count of trips for cabs by medallion with pick up or drop off date per basis between from and to inclusive.
+// The range runs from midnight starting from to midnight ending to in the time zone of the dates.
+func (q *Queryer) TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) ([]output.Result, error) {
+	var res []output.Result
+	datetime, _, _ := columns(basis)
+	rawQuery := fmt.Sprintf(`
+		SELECT
+			medallion,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion IN (?)
+		AND
+			%[1]s >= ?
+		AND
+			%[1]s < ?
+		GROUP BY medallion;
+	`, datetime)
+	query, args, err := sqlx.In(rawQuery, medallions, q.recorded(from), q.recorded(to.AddDate(0, 0, 1)))
+	if err != nil {
+		q.logger.Error("sql error on binding medallions", zap.Error(err))
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+	err = q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// TripsByMedallionPerDay get the count of trips for a cab by medallion for each pick up or drop off date per basis between from and to inclusive.
+// Dates without trips are not returned.
+func (q *Queryer) TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis) ([]output.DailyResult, error) {
+	var res []output.DailyResult
+	datetime, _, _ := columns(basis)
+	query := fmt.Sprintf(`
+		SELECT
+			DATE_FORMAT(DATE(%[1]s), '%%Y-%%m-%%d') AS date,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion = ?
+		AND
+			%[1]s >= ?
+		AND
+			%[1]s < ?
+		GROUP BY DATE(%[1]s)
+		ORDER BY date;
+	`, datetime)
+	err := q.db.SelectContext(ctx, &res, q.db.Rebind(query), medallion, startOf(from), endOf(to))
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// Metrics get the count, distance, duration, passengers and first and last pick up of trips for cabs by medallion.
+// Trips are filtered by pick up or drop off date per basis, zero from or to counts trips for all dates.
+// The from and to dates are the midnights starting the first and last dates in their time zone.
+// Medallions without trips are not returned.
+func (q *Queryer) Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) ([]output.Metrics, error) {
+	var res []output.Metrics
+	rawQuery := `
+		SELECT
+			medallion,
+			count(medallion) AS trips,
+			SUM(trip_distance) AS total_distance,
+			AVG(trip_distance) AS average_distance,
+			SUM(trip_time_in_secs) AS total_duration,
+			AVG(trip_time_in_secs) AS average_duration,
+			SUM(passenger_count) AS passengers,
+			MIN(pickup_datetime) AS first_pickup,
+			MAX(pickup_datetime) AS last_pickup
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion IN (?)
+	`
+	args := []interface{}{medallions}
+	if !from.IsZero() && !to.IsZero() {
+		datetime, _, _ := columns(basis)
+		rawQuery += `AND ` + datetime + ` >= ? AND ` + datetime + ` < ?
+	`
+		args = append(args, q.recorded(from), q.recorded(to.AddDate(0, 0, 1)))
+	}
+	rawQuery += `GROUP BY medallion;`
+	query, args, err := sqlx.In(rawQuery, args...)
+	if err != nil {
+		q.logger.Error("sql error on binding medallions", zap.Error(err))
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+	err = q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// histogramRow is the count of trips for an hour of day on a day of week.
+type histogramRow struct {
+	Hour    int `db:"hour"`
+	Weekday int `db:"weekday"`
+	Trips   int `db:"trips"`
+}
+
+// PickUpHistogram get the count of trips for cabs by medallion bucketed by hour of day and day of week of pick up or drop off per basis.
+// Zero from or to leaves the date range open on that side.
+func (q *Queryer) PickUpHistogram(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (output.Histogram, error) {
+	var rows []histogramRow
+	datetime, _, _ := columns(basis)
+	rawQuery := fmt.Sprintf(`
+		SELECT
+			HOUR(%[1]s) AS hour,
+			DAYOFWEEK(%[1]s) - 1 AS weekday,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion IN (?)
+	`, datetime)
+	args := []interface{}{medallions}
+	if !from.IsZero() {
+		rawQuery += `AND ` + datetime + ` >= ?
+	`
+		args = append(args, startOf(from))
+	}
+	if !to.IsZero() {
+		rawQuery += `AND ` + datetime + ` < ?
+	`
+		args = append(args, endOf(to))
+	}
+	rawQuery += `GROUP BY hour, weekday;`
+	query, args, err := sqlx.In(rawQuery, args...)
+	if err != nil {
+		q.logger.Error("sql error on binding medallions", zap.Error(err))
+		return output.Histogram{}, errors.Wrap(err, "failed to build query")
+	}
+	err = q.db.SelectContext(ctx, &rows, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return output.Histogram{}, errors.Wrap(classify(err), "failed to query")
+	}
+	res := output.Histogram{Medallions: medallions}
+	for _, r := range rows {
+		if r.Hour < 0 || r.Hour >= len(res.HourOfDay) || r.Weekday < 0 || r.Weekday >= len(res.DayOfWeek) {
+			continue
+		}
+		res.HourOfDay[r.Hour] += r.Trips
+		res.DayOfWeek[r.Weekday] += r.Trips
+	}
+	return res, nil
+}
+
+// TripsByMedallionsInChunks get the count of trips for cabs by medallion querying at most chunkSize medallions at a time.
+// fn is called with the medallions of each chunk and their results as soon as the chunk completes,
+// medallions without trips are not returned.
+// Zero from or to counts trips for all dates.
+func (q *Queryer) TripsByMedallionsInChunks(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, fn func([]string, []output.Result) error) error {
+	for start := 0; start < len(medallions); start += chunkSize {
+		end := start + chunkSize
+		if end > len(medallions) {
+			end = len(medallions)
+		}
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(classify(err), "query aborted")
+		}
+		var res []output.Result
+		var err error
+		if from.IsZero() || to.IsZero() {
+			res, err = q.TripsByMedallion(ctx, medallions[start:end])
+		} else {
+			res, err = q.TripsByMedallionsInDateRange(ctx, medallions[start:end], from, to, basis)
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(medallions[start:end], res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trips get the trips of a cab by medallion ordered by pick up then drop off time,
+// or by drop off then pick up time for the drop off basis.
+// Zero from or to leaves the date range open on that side.
+// A non nil after returns only trips following the cursor, skipping the trips at its position already listed.
+func (q *Queryer) Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) ([]output.Trip, error) {
+	var res []output.Trip
+	first, second := "pickup_datetime", "dropoff_datetime"
+	var firstAfter, secondAfter time.Time
+	if after != nil {
+		firstAfter, secondAfter = after.PickUpDateTime, after.DropOffDateTime
+	}
+	if basis == output.DropOff {
+		first, second = second, first
+		firstAfter, secondAfter = secondAfter, firstAfter
+	}
+	query := `
+		SELECT
+			medallion,
+			pickup_datetime,
+			dropoff_datetime,
+			passenger_count,
+			trip_time_in_secs,
+			trip_distance,
+			pickup_longitude,
+			pickup_latitude,
+			dropoff_longitude,
+			dropoff_latitude
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion = ?
+	`
+	args := []interface{}{medallion}
+	if !from.IsZero() {
+		query += `AND ` + first + ` >= ?
+	`
+		args = append(args, startOf(from))
+	}
+	if !to.IsZero() {
+		query += `AND ` + first + ` < ?
+	`
+		args = append(args, endOf(to))
+	}
+	if after != nil {
+		query += fmt.Sprintf(`AND (%[1]s > ? OR (%[1]s = ? AND %[2]s >= ?))
+	`, first, second)
+		args = append(args, firstAfter, firstAfter, secondAfter)
+	}
+	query += `ORDER BY ` + first + `, ` + second + `, ` + tieColumns + `
+		LIMIT ?`
+	args = append(args, limit)
+	if after != nil {
+		query += ` OFFSET ?`
+		args = append(args, after.Skip)
+	}
+	query += `;`
+	err := q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// TopMedallions get the medallions with the most trips, ordered by trips descending then medallion.
+// Zero from or to leaves the date range open on that side.
+func (q *Queryer) TopMedallions(ctx context.Context, from, to time.Time, basis output.Basis, limit int) ([]output.Result, error) {
+	var res []output.Result
+	datetime, _, _ := columns(basis)
+	query := `
+		SELECT
+			medallion,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+	`
+	var conditions []string
+	var args []interface{}
+	if !from.IsZero() {
+		conditions = append(conditions, datetime+" >= ?")
+		args = append(args, startOf(from))
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, datetime+" < ?")
+		args = append(args, endOf(to))
+	}
+	if len(conditions) > 0 {
+		query += `WHERE ` + strings.Join(conditions, " AND ") + `
+	`
+	}
+	query += `GROUP BY medallion
+		ORDER BY trips DESC, medallion
+		LIMIT ?;`
+	args = append(args, limit)
+	err := q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	return res, nil
+}
+
+// driverRow is the count of trips for a driver in a cab.
+type driverRow struct {
+	HackLicense string `db:"hack_license"`
+	Medallion   string `db:"medallion"`
+	Trips       int    `db:"trips"`
+}
+
+// TripsByDriver get the count of trips and the medallions operated by drivers by hack license.
+// Drivers without trips are not returned.
+func (q *Queryer) TripsByDriver(ctx context.Context, licenses []string) ([]output.DriverResult, error) {
+	var rows []driverRow
+	rawQuery := `
+		SELECT
+			hack_license,
+			medallion,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			hack_license IN (?)
+		GROUP BY hack_license, medallion
+		ORDER BY hack_license, medallion;
+	`
+	query, args, err := sqlx.In(rawQuery, licenses)
+	if err != nil {
+		q.logger.Error("sql error on binding licenses", zap.Error(err))
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+	err = q.db.SelectContext(ctx, &rows, q.db.Rebind(query), args...)
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return nil, errors.Wrap(classify(err), "failed to query")
+	}
+	var res []output.DriverResult
+	for _, r := range rows {
+		if len(res) == 0 || res[len(res)-1].HackLicense != r.HackLicense {
+			res = append(res, output.DriverResult{HackLicense: r.HackLicense})
+		}
+		last := &res[len(res)-1]
+		last.Trips += r.Trips
+		last.Medallions = append(last.Medallions, r.Medallion)
+	}
+	return res, nil
+}
+
+// TripsByDriverOnPickUpDate get the count of trips and the medallions operated by a driver by hack license and pick up or drop off date per basis.
+// The date runs from the midnight of pickUpDate to the next midnight in its time zone.
+func (q *Queryer) TripsByDriverOnPickUpDate(ctx context.Context, license string, pickUpDate time.Time, basis output.Basis) (output.DriverResult, error) {
+	var rows []driverRow
+	datetime, _, _ := columns(basis)
+	query := fmt.Sprintf(`
+		SELECT
+			hack_license,
+			medallion,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			hack_license = ?
+		AND
+			%[1]s >= ?
+		AND
+			%[1]s < ?
+		GROUP BY hack_license, medallion
+		ORDER BY medallion;
+	`, datetime)
+	err := q.db.SelectContext(ctx, &rows, q.db.Rebind(query), license, q.recorded(pickUpDate), q.recorded(pickUpDate.AddDate(0, 0, 1)))
+	if err != nil {
+		q.logger.Error("sql error on query", zap.Error(err))
+		return output.DriverResult{}, errors.Wrap(classify(err), "failed to query")
+	}
+	res := output.DriverResult{HackLicense: license, Medallions: []string{}}
+	for _, r := range rows {
+		res.Trips += r.Trips
+		res.Medallions = append(res.Medallions, r.Medallion)
+	}
+	return res, nil
+}
+
+// TripsInArea get the count of trips per medallion with pick up or drop off per basis inside the area and between from and to inclusive.
+// Empty medallions counts trips of all medallions.
+func (q *Queryer) TripsInArea(ctx context.Context, area geo.Area, from, to time.Time, basis output.Basis, medallions []string) ([]output.Result, error) {
+	var res []output.Result
+	datetime, latitude, longitude := columns(basis)
+	rawQuery := fmt.Sprintf(`
+		SELECT
+			medallion,
+			count(medallion) AS trips
+		FROM
+			cab_trip_data
+		WHERE
+			%[2]s BETWEEN ? AND ?
+		AND
+			%[3]s BETWEEN ? AND ?
+		AND
+			%[1]s >= ?
+		AND
+			%[1]s < ?
+	`, datetime, latitude, longitude)
+	args := []interface{}{area.Box.Min.Lat, area.Box.Max.Lat, area.Box.Min.Lng, area.Box.Max.Lng, startOf(from), endOf(to)}
+	if area.IsRadius() {
+		rawQuery += fmt.Sprintf(`AND ST_Distance_Sphere(POINT(%s, %s), POINT(?, ?), ?) <= ?
+	`, longitude, latitude)
+		args = append(args, area.Center.Lng, area.Center.Lat, geo.EarthRadius, area.Radius)
+	}
+	if len(medallions) > 0 {
+		rawQuery += `AND medallion IN (?)
+	`
+		args = append(args, medallions)
+	}
+	rawQuery += `GROUP BY medallion;`
+	query, args, err := sqlx.In(rawQuery, args...)
+	if err != nil {
+		q.logger.Error("sql error on binding medallions", zap.Error(err))
+		return nil, errors.Wrap(err, "failed to build query")
+	}
+	err = q.db.SelectContext(ctx, &res, q.db.Rebind(query), args...)
 	if err != nil {
 		q.logger.Error("sql error on query", zap.Error(err))
-		return nil, errors.Wrap(err, "failed to query")
+		return nil, errors.Wrap(classify(err), "failed to query")
 	}
 	return res, nil
 }
@@ -3,6 +3,7 @@ package database_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -13,21 +14,26 @@ import (
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 
 	"github.com/nikhil-github/api-cab-data/pkg/database"
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
 	"github.com/nikhil-github/api-cab-data/pkg/output"
 )
 
 func TestTripsByMedallionOnPickUpDate(t *testing.T) {
-	pDate := time.Date(2013, 12, 31, 0, 1, 0, 0, time.UTC)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	pDate := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	pDateEnd := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
 	type args struct {
-		Medallion  string
+		Medallions []string
 		PickUpDate time.Time
+		Zone       *time.Location
 	}
 	type fields struct {
 		MockOperations func(sqlmock.Sqlmock)
 	}
 	type want struct {
 		Error  string
-		Result output.Result
+		Result []output.Result
 	}
 
 	testTable := []struct {
@@ -38,20 +44,101 @@ func TestTripsByMedallionOnPickUpDate(t *testing.T) {
 	}{
 		{
 			Name: "Success, record found",
-			Args: args{Medallion: "67EB082BFFE72095EAF18488BEA96050", PickUpDate: pDate},
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: pDate, Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				columns := []string{"medallion", "trips"}
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 1)
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", pDate, pDateEnd).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 1}}},
+		},
+		{
+			Name: "Success, multiple medallions",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050", "D7D598CD99978BD012A87A76A7C891B7"}, PickUpDate: pDate, Zone: time.UTC},
 			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
-				columns := []string{"count"}
+				columns := []string{"medallion", "trips"}
 				rows := sqlmock.NewRows(columns)
-				rows.AddRow(1)
-				selectCount(m, "67EB082BFFE72095EAF18488BEA96050", pDate).WillReturnRows(rows)
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 1)
+				rows.AddRow("D7D598CD99978BD012A87A76A7C891B7", 3)
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", "D7D598CD99978BD012A87A76A7C891B7", pDate, pDateEnd).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 1}, {Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 3}}},
+		},
+		{
+			Name: "Success, New York day recorded in New York",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", pDate, pDateEnd).WillReturnRows(rows)
+			}},
+			Want: want{},
+		},
+		{
+			Name: "Success, UTC day recorded in New York",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: pDate, Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 12, 30, 19, 0, 0, 0, time.UTC), time.Date(2013, 12, 31, 19, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{},
+		},
+		{
+			Name: "Success, UTC day recorded in New York ending in standard time",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: time.Date(2013, 11, 3, 0, 0, 0, 0, time.UTC), Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 11, 2, 20, 0, 0, 0, time.UTC), time.Date(2013, 11, 3, 19, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{},
+		},
+		{
+			Name: "Success, New York day of 23 hours recorded in UTC",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: time.Date(2013, 3, 10, 0, 0, 0, 0, newYork), Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 3, 10, 5, 0, 0, 0, time.UTC), time.Date(2013, 3, 11, 4, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{},
+		},
+		{
+			Name: "Success, New York day of 25 hours recorded in UTC",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 11, 3, 4, 0, 0, 0, time.UTC), time.Date(2013, 11, 4, 5, 0, 0, 0, time.UTC)).WillReturnRows(rows)
 			}},
-			Want: want{Result: output.Result{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 1}},
+			Want: want{},
+		},
+		{
+			Name: "Success, New York day of 23 hours recorded in New York",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: time.Date(2013, 3, 10, 0, 0, 0, 0, newYork), Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2013, 3, 11, 0, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{},
+		},
+		{
+			Name: "Success, New York day of 25 hours recorded in New York",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, PickUpDate: time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				selectCount(m).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 11, 3, 0, 0, 0, 0, time.UTC), time.Date(2013, 11, 4, 0, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{},
 		},
 		{
 			Name: "Failure, DB error",
-			Args: args{Medallion: "55EB082BFFE795EAF18488BEA96050", PickUpDate: pDate},
+			Args: args{Medallions: []string{"55EB082BFFE795EAF18488BEA96050"}, PickUpDate: pDate, Zone: time.UTC},
 			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
-				selectCount(m, "55EB082BFFE795EAF18488BEA96050", pDate).WillReturnError(errors.New("sql error"))
+				selectCount(m).WithArgs("55EB082BFFE795EAF18488BEA96050", pDate, pDateEnd).WillReturnError(errors.New("sql error"))
 			}},
 			Want: want{Error: "failed to query: sql error"},
 		},
@@ -65,8 +152,8 @@ func TestTripsByMedallionOnPickUpDate(t *testing.T) {
 			defer db.Close()
 			tt.Fields.MockOperations(mock)
 
-			dao := database.NewQueryer(db, zap.NewNop())
-			res, err := dao.TripsByMedallionsOnPickUpDate(context.Background(), tt.Args.Medallion, tt.Args.PickUpDate)
+			dao := database.NewQueryer(db, tt.Args.Zone, zap.NewNop())
+			res, err := dao.TripsByMedallionsOnPickUpDate(context.Background(), tt.Args.Medallions, tt.Args.PickUpDate, output.PickUp)
 			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
 			if tt.Want.Error != "" {
 				assert.EqualError(t, err, tt.Want.Error, "Error")
@@ -78,19 +165,21 @@ func TestTripsByMedallionOnPickUpDate(t *testing.T) {
 	}
 }
 
-func selectCount(m sqlmock.Sqlmock, medallion string, pDate time.Time) *sqlmock.ExpectedQuery {
+func selectCount(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
 	return m.ExpectQuery(`
 		SELECT
-			count\(medallion\)
-		AS
-			count
+			medallion,
+			count\(medallion\) AS trips
 		FROM
 			cab_trip_data
 		WHERE	
-			medallion = \?
+			medallion IN \((\?, )*\?\)
+		AND
+			pickup_datetime >= \?
 		AND
-			DATE\(pickup_datetime\) = DATE\(\?\)
-	`).WithArgs(medallion, pDate)
+			pickup_datetime < \?
+		GROUP BY medallion
+	`)
 }
 
 func TestTripsByMedallion(t *testing.T) {
@@ -140,7 +229,7 @@ func TestTripsByMedallion(t *testing.T) {
 			defer db.Close()
 			tt.Fields.MockOperations(mock)
 
-			dao := database.NewQueryer(db, zap.NewNop())
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
 			res, err := dao.TripsByMedallion(context.Background(), tt.Args.Medallions)
 			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
 			if tt.Want.Error != "" {
@@ -161,7 +250,1061 @@ func selectCounts(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
 		FROM
 			cab_trip_data
 		WHERE	
-			medallion IN \(\?\)
+			medallion IN \((\?, )*\?\)
+		GROUP BY medallion
+	`)
+}
+
+func TestMedallionSummaries(t *testing.T) {
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.MedallionSummary
+	}
+
+	testTable := []struct {
+		Name   string
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, medallions found",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips", "first_seen", "last_seen"})
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 39, "2013-12-01", "2013-12-31")
+				selectSummaries(m).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.MedallionSummary{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 39, FirstSeen: "2013-12-01", LastSeen: "2013-12-31"}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectSummaries(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.MedallionSummaries(context.Background())
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectSummaries(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			medallion,
+			count\(medallion\) AS trips,
+			DATE_FORMAT\(MIN\(pickup_datetime\), '%Y-%m-%d'\) AS first_seen,
+			DATE_FORMAT\(MAX\(pickup_datetime\), '%Y-%m-%d'\) AS last_seen
+		FROM
+			cab_trip_data
+		GROUP BY medallion
+		ORDER BY medallion;
+	`)
+}
+
+func TestTripsByMedallionsInDateRange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Medallions []string
+		From       time.Time
+		To         time.Time
+		Basis      output.Basis
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.Result
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, record found",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050", "D7D598CD99978BD012A87A76A7C891B7"}, From: from, To: to, Basis: output.PickUp},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				columns := []string{"medallion", "trips"}
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 12)
+				rows.AddRow("D7D598CD99978BD012A87A76A7C891B7", 3)
+				selectRangeCounts(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", "D7D598CD99978BD012A87A76A7C891B7", from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 12}, {Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 3}}},
+		},
+		{
+			Name: "Success, drop off basis",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, From: from, To: to, Basis: output.DropOff},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 11)
+				m.ExpectQuery(`dropoff_datetime >= \?\s+AND\s+dropoff_datetime < \?`).WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 11}}},
+		},
+		{
+			Name: "Success, New York range across the end of daylight saving",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, From: time.Date(2013, 11, 1, 0, 0, 0, 0, newYork), To: time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), Basis: output.PickUp},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 9)
+				selectRangeCounts(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", time.Date(2013, 11, 1, 4, 0, 0, 0, time.UTC), time.Date(2013, 11, 4, 5, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 9}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Medallions: []string{"55EB082BFFE795EAF18488BEA96050"}, From: from, To: to, Basis: output.PickUp},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectRangeCounts(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.TripsByMedallionsInDateRange(context.Background(), tt.Args.Medallions, tt.Args.From, tt.Args.To, tt.Args.Basis)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectRangeCounts(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			medallion,
+			count\(medallion\) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion IN \((\?, )*\?\)
+		AND
+			pickup_datetime >= \?
+		AND
+			pickup_datetime < \?
 		GROUP BY medallion
 	`)
 }
+
+func TestTripsByMedallionPerDay(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 3, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Medallion string
+		From      time.Time
+		To        time.Time
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.DailyResult
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, record found",
+			Args: args{Medallion: "67EB082BFFE72095EAF18488BEA96050", From: from, To: to},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				columns := []string{"date", "trips"}
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow("2013-12-01", 4)
+				rows.AddRow("2013-12-03", 7)
+				selectDailyCounts(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.DailyResult{{Date: "2013-12-01", Trips: 4}, {Date: "2013-12-03", Trips: 7}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Medallion: "55EB082BFFE795EAF18488BEA96050", From: from, To: to},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectDailyCounts(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.TripsByMedallionPerDay(context.Background(), tt.Args.Medallion, tt.Args.From, tt.Args.To, output.PickUp)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectDailyCounts(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			DATE_FORMAT\(DATE\(pickup_datetime\), '%Y-%m-%d'\) AS date,
+			count\(medallion\) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion = \?
+		AND
+			pickup_datetime >= \?
+		AND
+			pickup_datetime < \?
+		GROUP BY DATE\(pickup_datetime\)
+		ORDER BY date
+	`)
+}
+
+func TestPickUpHistogram(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Medallions []string
+		From       time.Time
+		To         time.Time
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result output.Histogram
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, without date range",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				columns := []string{"hour", "weekday", "trips"}
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow(8, 1, 3)
+				rows.AddRow(8, 2, 2)
+				rows.AddRow(23, 6, 5)
+				m.ExpectQuery(`medallion IN \(\?\)\s+GROUP BY hour, weekday`).WithArgs("67EB082BFFE72095EAF18488BEA96050").WillReturnRows(rows)
+			}},
+			Want: want{Result: func() output.Histogram {
+				h := output.Histogram{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}}
+				h.HourOfDay[8] = 5
+				h.HourOfDay[23] = 5
+				h.DayOfWeek[1] = 3
+				h.DayOfWeek[2] = 2
+				h.DayOfWeek[6] = 5
+				return h
+			}()},
+		},
+		{
+			Name: "Success, with date range",
+			Args: args{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}, From: from, To: to},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				columns := []string{"hour", "weekday", "trips"}
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow(0, 0, 1)
+				selectHistogram(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: func() output.Histogram {
+				h := output.Histogram{Medallions: []string{"67EB082BFFE72095EAF18488BEA96050"}}
+				h.HourOfDay[0] = 1
+				h.DayOfWeek[0] = 1
+				return h
+			}()},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Medallions: []string{"55EB082BFFE795EAF18488BEA96050"}, From: from, To: to},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectHistogram(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.PickUpHistogram(context.Background(), tt.Args.Medallions, tt.Args.From, tt.Args.To, output.PickUp)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectHistogram(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			HOUR\(pickup_datetime\) AS hour,
+			DAYOFWEEK\(pickup_datetime\) - 1 AS weekday,
+			count\(medallion\) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion IN \(\?\)
+	AND pickup_datetime >= \?
+	AND pickup_datetime < \?
+	GROUP BY hour, weekday
+	`)
+}
+
+func TestTripsByMedallionsInChunks(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	medallions := make([]string, 501)
+	for i := range medallions {
+		medallions[i] = fmt.Sprintf("MED%d", i)
+	}
+	type args struct {
+		Medallions []string
+		From       time.Time
+		To         time.Time
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Chunks [][]output.Result
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, all dates in two chunks",
+			Args: args{Medallions: medallions},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("MED0", 4)
+				selectCounts(m).WillReturnRows(rows)
+				rows = sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("MED500", 2)
+				selectCounts(m).WithArgs("MED500").WillReturnRows(rows)
+			}},
+			Want: want{Chunks: [][]output.Result{{{Medallion: "MED0", Trips: 4}}, {{Medallion: "MED500", Trips: 2}}}},
+		},
+		{
+			Name: "Success, date range in one chunk",
+			Args: args{Medallions: medallions[:2], From: from, To: to},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("MED1", 3)
+				selectRangeCounts(m).WithArgs("MED0", "MED1", from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Chunks: [][]output.Result{{{Medallion: "MED1", Trips: 3}}}},
+		},
+		{
+			Name: "Failure, DB error stops remaining chunks",
+			Args: args{Medallions: medallions},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectCounts(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			var chunks [][]output.Result
+			var chunked []string
+			err = dao.TripsByMedallionsInChunks(context.Background(), tt.Args.Medallions, tt.Args.From, tt.Args.To, output.PickUp, func(meds []string, res []output.Result) error {
+				chunked = append(chunked, meds...)
+				chunks = append(chunks, res)
+				return nil
+			})
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Chunks, chunks, "Chunks")
+			assert.Equal(t, tt.Args.Medallions, chunked, "every medallion is passed with its chunk")
+		})
+	}
+}
+
+func TestTrips(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	pickUp := time.Date(2013, 12, 1, 10, 0, 0, 0, time.UTC)
+	dropOff := time.Date(2013, 12, 1, 10, 12, 0, 0, time.UTC)
+	trip := output.Trip{
+		Medallion:        "67EB082BFFE72095EAF18488BEA96050",
+		PickUpDateTime:   pickUp,
+		DropOffDateTime:  dropOff,
+		PassengerCount:   2,
+		TripTimeInSecs:   720,
+		TripDistance:     3.1,
+		PickUpLongitude:  -73.98,
+		PickUpLatitude:   40.75,
+		DropOffLongitude: -73.95,
+		DropOffLatitude:  40.78,
+	}
+	columns := []string{"medallion", "pickup_datetime", "dropoff_datetime", "passenger_count", "trip_time_in_secs", "trip_distance", "pickup_longitude", "pickup_latitude", "dropoff_longitude", "dropoff_latitude"}
+	type args struct {
+		Medallion string
+		From      time.Time
+		To        time.Time
+		Basis     output.Basis
+		After     *output.Cursor
+		Limit     int
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.Trip
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, first page",
+			Args: args{Medallion: "67EB082BFFE72095EAF18488BEA96050", Limit: 10},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow(trip.Medallion, pickUp, dropOff, 2, 720, 3.1, -73.98, 40.75, -73.95, 40.78)
+				m.ExpectQuery(`medallion = \?\s+ORDER BY pickup_datetime, dropoff_datetime, passenger_count, trip_time_in_secs, trip_distance, pickup_longitude, pickup_latitude, dropoff_longitude, dropoff_latitude\s+LIMIT \?;`).WithArgs("67EB082BFFE72095EAF18488BEA96050", 10).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Trip{trip}},
+		},
+		{
+			Name: "Success, date range after cursor",
+			Args: args{Medallion: "67EB082BFFE72095EAF18488BEA96050", From: from, To: to, After: &output.Cursor{PickUpDateTime: pickUp, DropOffDateTime: dropOff, Skip: 1}, Limit: 10},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				selectTrips(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1), pickUp, pickUp, dropOff, 10, 1).WillReturnRows(rows)
+			}},
+			Want: want{Result: nil},
+		},
+		{
+			Name: "Success, skips trips at cursor already listed",
+			Args: args{Medallion: "67EB082BFFE72095EAF18488BEA96050", From: from, To: to, After: &output.Cursor{PickUpDateTime: pickUp, DropOffDateTime: dropOff, Skip: 3}, Limit: 10},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow(trip.Medallion, pickUp, dropOff, 2, 720, 3.1, -73.98, 40.75, -73.95, 40.78)
+				selectTrips(m).WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1), pickUp, pickUp, dropOff, 10, 3).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Trip{trip}},
+		},
+		{
+			Name: "Success, drop off basis after cursor",
+			Args: args{Medallion: "67EB082BFFE72095EAF18488BEA96050", From: from, To: to, Basis: output.DropOff, After: &output.Cursor{PickUpDateTime: pickUp, DropOffDateTime: dropOff, Skip: 1}, Limit: 10},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				m.ExpectQuery(`AND dropoff_datetime >= \?\s+AND dropoff_datetime < \?\s+`+
+					`AND \(dropoff_datetime > \? OR \(dropoff_datetime = \? AND pickup_datetime >= \?\)\)\s+ORDER BY dropoff_datetime, pickup_datetime, passenger_count`).
+					WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1), dropOff, dropOff, pickUp, 10, 1).WillReturnRows(rows)
+			}},
+			Want: want{Result: nil},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Medallion: "55EB082BFFE795EAF18488BEA96050", From: from, To: to, After: &output.Cursor{PickUpDateTime: pickUp, DropOffDateTime: dropOff}, Limit: 10},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectTrips(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.Trips(context.Background(), tt.Args.Medallion, tt.Args.From, tt.Args.To, tt.Args.Basis, tt.Args.After, tt.Args.Limit)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectTrips(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		FROM
+			cab_trip_data
+		WHERE	
+			medallion = \?
+	AND pickup_datetime >= \?
+	AND pickup_datetime < \?
+	AND \(pickup_datetime > \? OR \(pickup_datetime = \? AND dropoff_datetime >= \?\)\)
+	ORDER BY pickup_datetime, dropoff_datetime, passenger_count, trip_time_in_secs, trip_distance, pickup_longitude, pickup_latitude, dropoff_longitude, dropoff_latitude
+		LIMIT \? OFFSET \?;`)
+}
+
+func TestTopMedallions(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		From  time.Time
+		To    time.Time
+		Limit int
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.Result
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, all dates",
+			Args: args{Limit: 2},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 40)
+				rows.AddRow("D7D598CD99978BD012A87A76A7C891B7", 3)
+				m.ExpectQuery(`FROM\s+cab_trip_data\s+GROUP BY medallion\s+ORDER BY trips DESC, medallion\s+LIMIT \?`).WithArgs(2).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 40}, {Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 3}}},
+		},
+		{
+			Name: "Success, date range",
+			Args: args{From: from, To: to, Limit: 1},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 12)
+				selectTop(m).WithArgs(from, to.AddDate(0, 0, 1), 1).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 12}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{From: from, To: to, Limit: 1},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectTop(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.TopMedallions(context.Background(), tt.Args.From, tt.Args.To, output.PickUp, tt.Args.Limit)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectTop(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			medallion,
+			count\(medallion\) AS trips
+		FROM
+			cab_trip_data
+	WHERE pickup_datetime >= \? AND pickup_datetime < \?
+	GROUP BY medallion
+		ORDER BY trips DESC, medallion
+		LIMIT \?
+	`)
+}
+
+func TestTripsByDriver(t *testing.T) {
+	type args struct {
+		Licenses []string
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.DriverResult
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, record found",
+			Args: args{Licenses: []string{"AAAA", "BBBB"}},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"hack_license", "medallion", "trips"})
+				rows.AddRow("AAAA", "MED1", 3)
+				rows.AddRow("AAAA", "MED2", 2)
+				rows.AddRow("BBBB", "MED1", 1)
+				selectDriverCounts(m).WithArgs("AAAA", "BBBB").WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.DriverResult{{HackLicense: "AAAA", Trips: 5, Medallions: []string{"MED1", "MED2"}}, {HackLicense: "BBBB", Trips: 1, Medallions: []string{"MED1"}}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Licenses: []string{"CCCC"}},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectDriverCounts(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.TripsByDriver(context.Background(), tt.Args.Licenses)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectDriverCounts(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			hack_license,
+			medallion,
+			count\(medallion\) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			hack_license IN \((\?, )*\?\)
+		GROUP BY hack_license, medallion
+		ORDER BY hack_license, medallion
+	`)
+}
+
+func TestTripsByDriverOnPickUpDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	pDate := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		License    string
+		PickUpDate time.Time
+		Zone       *time.Location
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result output.DriverResult
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, record found",
+			Args: args{License: "AAAA", PickUpDate: pDate, Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"hack_license", "medallion", "trips"})
+				rows.AddRow("AAAA", "MED1", 3)
+				rows.AddRow("AAAA", "MED2", 2)
+				selectDriverDateCounts(m).WithArgs("AAAA", pDate, pDate.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: output.DriverResult{HackLicense: "AAAA", Trips: 5, Medallions: []string{"MED1", "MED2"}}},
+		},
+		{
+			Name: "Success, no trips",
+			Args: args{License: "BBBB", PickUpDate: pDate, Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"hack_license", "medallion", "trips"})
+				selectDriverDateCounts(m).WithArgs("BBBB", pDate, pDate.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: output.DriverResult{HackLicense: "BBBB", Medallions: []string{}}},
+		},
+		{
+			Name: "Success, New York day of 23 hours recorded in UTC",
+			Args: args{License: "AAAA", PickUpDate: time.Date(2013, 3, 10, 0, 0, 0, 0, newYork), Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"hack_license", "medallion", "trips"})
+				selectDriverDateCounts(m).WithArgs("AAAA", time.Date(2013, 3, 10, 5, 0, 0, 0, time.UTC), time.Date(2013, 3, 11, 4, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{Result: output.DriverResult{HackLicense: "AAAA", Medallions: []string{}}},
+		},
+		{
+			Name: "Success, New York day of 25 hours recorded in New York",
+			Args: args{License: "AAAA", PickUpDate: time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"hack_license", "medallion", "trips"})
+				selectDriverDateCounts(m).WithArgs("AAAA", time.Date(2013, 11, 3, 0, 0, 0, 0, time.UTC), time.Date(2013, 11, 4, 0, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{Result: output.DriverResult{HackLicense: "AAAA", Medallions: []string{}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{License: "CCCC", PickUpDate: pDate, Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectDriverDateCounts(m).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, tt.Args.Zone, zap.NewNop())
+			res, err := dao.TripsByDriverOnPickUpDate(context.Background(), tt.Args.License, tt.Args.PickUpDate, output.PickUp)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+func selectDriverDateCounts(m sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`
+		SELECT
+			hack_license,
+			medallion,
+			count\(medallion\) AS trips
+		FROM
+			cab_trip_data
+		WHERE	
+			hack_license = \?
+		AND
+			pickup_datetime >= \?
+		AND
+			pickup_datetime < \?
+		GROUP BY hack_license, medallion
+		ORDER BY medallion
+	`)
+}
+
+func TestTripsInArea(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	box := geo.Box{Min: geo.Point{Lat: 40.7, Lng: -74.0}, Max: geo.Point{Lat: 40.8, Lng: -73.9}}
+	center := geo.Point{Lat: 40.75, Lng: -73.95}
+	type args struct {
+		Area       geo.Area
+		Basis      output.Basis
+		Medallions []string
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.Result
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, bounding box",
+			Args: args{Area: geo.NewBoxArea(box)},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("MED1", 3)
+				rows.AddRow("MED2", 2)
+				selectArea(m, ``).WithArgs(40.7, 40.8, -74.0, -73.9, from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "MED1", Trips: 3}, {Medallion: "MED2", Trips: 2}}},
+		},
+		{
+			Name: "Success, radius with medallions",
+			Args: args{Area: geo.NewRadiusArea(center, 1000), Medallions: []string{"MED1", "MED2"}},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				b := geo.BoxAround(center, 1000)
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("MED1", 1)
+				selectArea(m, `AND ST_Distance_Sphere\(POINT\(pickup_longitude, pickup_latitude\), POINT\(\?, \?\), \?\) <= \? AND medallion IN \(\?, \?\) `).
+					WithArgs(b.Min.Lat, b.Max.Lat, b.Min.Lng, b.Max.Lng, from, to.AddDate(0, 0, 1), -73.95, 40.75, geo.EarthRadius, 1000.0, "MED1", "MED2").
+					WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "MED1", Trips: 1}}},
+		},
+		{
+			Name: "Success, drop off basis",
+			Args: args{Area: geo.NewRadiusArea(center, 1000), Basis: output.DropOff},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"medallion", "trips"})
+				rows.AddRow("MED2", 4)
+				m.ExpectQuery(`WHERE dropoff_latitude BETWEEN \? AND \? AND dropoff_longitude BETWEEN \? AND \? ` +
+					`AND dropoff_datetime >= \? AND dropoff_datetime < \? ` +
+					`AND ST_Distance_Sphere\(POINT\(dropoff_longitude, dropoff_latitude\), POINT\(\?, \?\), \?\) <= \? GROUP BY medallion;`).
+					WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Result{{Medallion: "MED2", Trips: 4}}},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Area: geo.NewBoxArea(box)},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectArea(m, ``).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, time.UTC, zap.NewNop())
+			res, err := dao.TripsInArea(context.Background(), tt.Args.Area, from, to, tt.Args.Basis, tt.Args.Medallions)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+// selectArea expects the area query with the optional conditions in between the date range and grouping.
+func selectArea(m sqlmock.Sqlmock, conditions string) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`^SELECT medallion, count\(medallion\) AS trips FROM cab_trip_data ` +
+		`WHERE pickup_latitude BETWEEN \? AND \? AND pickup_longitude BETWEEN \? AND \? ` +
+		`AND pickup_datetime >= \? AND pickup_datetime < \? ` + conditions +
+		`GROUP BY medallion;$`)
+}
+
+func TestMetrics(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	first := time.Date(2013, 12, 1, 8, 0, 0, 0, time.UTC)
+	last := time.Date(2013, 12, 30, 22, 0, 0, 0, time.UTC)
+	columns := []string{"medallion", "trips", "total_distance", "average_distance", "total_duration", "average_duration", "passengers", "first_pickup", "last_pickup"}
+	type args struct {
+		From  time.Time
+		To    time.Time
+		Basis output.Basis
+		Zone  *time.Location
+	}
+	type fields struct {
+		MockOperations func(sqlmock.Sqlmock)
+	}
+	type want struct {
+		Error  string
+		Result []output.Metrics
+	}
+
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, all dates",
+			Args: args{Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				rows.AddRow("67EB082BFFE72095EAF18488BEA96050", 2, 5.5, 2.75, 1200, 600.0, 3, first, last)
+				selectMetrics(m, ``).WithArgs("67EB082BFFE72095EAF18488BEA96050").WillReturnRows(rows)
+			}},
+			Want: want{Result: []output.Metrics{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 2, TotalDistance: 5.5, AverageDistance: 2.75, TotalDuration: 1200, AverageDuration: 600, Passengers: 3, FirstPickUp: &first, LastPickUp: &last}}},
+		},
+		{
+			Name: "Success, drop off date range",
+			Args: args{From: from, To: to, Basis: output.DropOff, Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				selectMetrics(m, `AND dropoff_datetime >= \? AND dropoff_datetime < \? `).WithArgs("67EB082BFFE72095EAF18488BEA96050", from, to.AddDate(0, 0, 1)).WillReturnRows(rows)
+			}},
+			Want: want{Result: nil},
+		},
+		{
+			Name: "Success, New York dates of 23 and 25 hours recorded in UTC",
+			Args: args{From: time.Date(2013, 3, 10, 0, 0, 0, 0, newYork), To: time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				selectMetrics(m, `AND pickup_datetime >= \? AND pickup_datetime < \? `).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 3, 10, 5, 0, 0, 0, time.UTC), time.Date(2013, 11, 4, 5, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{Result: nil},
+		},
+		{
+			Name: "Success, New York dates recorded in New York",
+			Args: args{From: time.Date(2013, 3, 10, 0, 0, 0, 0, newYork), To: time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), Zone: newYork},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns)
+				selectMetrics(m, `AND pickup_datetime >= \? AND pickup_datetime < \? `).WithArgs("67EB082BFFE72095EAF18488BEA96050",
+					time.Date(2013, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2013, 11, 4, 0, 0, 0, 0, time.UTC)).WillReturnRows(rows)
+			}},
+			Want: want{Result: nil},
+		},
+		{
+			Name: "Failure, DB error",
+			Args: args{Zone: time.UTC},
+			Fields: fields{MockOperations: func(m sqlmock.Sqlmock) {
+				selectMetrics(m, ``).WillReturnError(errors.New("sql error"))
+			}},
+			Want: want{Error: "failed to query: sql error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err, "Unable to create Sqlmock DB")
+			db := sqlx.NewDb(mockDB, "mysql")
+			defer db.Close()
+			tt.Fields.MockOperations(mock)
+
+			dao := database.NewQueryer(db, tt.Args.Zone, zap.NewNop())
+			res, err := dao.Metrics(context.Background(), []string{"67EB082BFFE72095EAF18488BEA96050"}, tt.Args.From, tt.Args.To, tt.Args.Basis)
+			assert.NoError(t, mock.ExpectationsWereMet(), "DB Expectations")
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error, "Error")
+				return
+			}
+			require.NoError(t, err, "Unexpected error")
+			assert.Equal(t, tt.Want.Result, res, "Result")
+		})
+	}
+}
+
+// selectMetrics expects the metrics query with the optional date condition before grouping.
+func selectMetrics(m sqlmock.Sqlmock, conditions string) *sqlmock.ExpectedQuery {
+	return m.ExpectQuery(`^SELECT medallion, count\(medallion\) AS trips, ` +
+		`SUM\(trip_distance\) AS total_distance, AVG\(trip_distance\) AS average_distance, ` +
+		`SUM\(trip_time_in_secs\) AS total_duration, AVG\(trip_time_in_secs\) AS average_duration, ` +
+		`SUM\(passenger_count\) AS passengers, MIN\(pickup_datetime\) AS first_pickup, MAX\(pickup_datetime\) AS last_pickup ` +
+		`FROM cab_trip_data WHERE medallion IN \(\?\) ` + conditions + `GROUP BY medallion;$`)
+}
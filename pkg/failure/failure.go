@@ -0,0 +1,87 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel failures shared by the database, cache, service and handler layers.
+// Errors are matched with Is since they are usually wrapped with github.com/pkg/errors.
+var (
+	// ErrCacheMiss is a key without a cache entry.
+	ErrCacheMiss = errors.New("key not found in cache")
+	// ErrNotFound is a missing resource such as an unknown medallion.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput is a request that can not be served as is.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrUnavailable is a database that can not be reached.
+	ErrUnavailable = errors.New("database unavailable")
+	// ErrTimeout is a query that ran out of time.
+	ErrTimeout = errors.New("query timeout")
+	// ErrCanceled is a query abandoned by the caller.
+	ErrCanceled = errors.New("request canceled")
+)
+
+// InvalidError is an invalid value of a request field.
+// It matches ErrInvalidInput.
+type InvalidError struct {
+	Field  string
+	Reason string
+}
+
+// Invalid creates the error of an invalid field.
+func Invalid(field, reason string) error {
+	return &InvalidError{Field: field, Reason: reason}
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+// marked is an error marked as a failure, keeping its own message.
+type marked struct {
+	err  error
+	kind error
+}
+
+func (m *marked) Error() string { return m.err.Error() }
+
+// Cause returns the marked error to github.com/pkg/errors.
+func (m *marked) Cause() error { return m.err }
+
+// Mark marks err as the failure kind, nil stays nil.
+func Mark(err, kind error) error {
+	if err == nil {
+		return nil
+	}
+	return &marked{err: err, kind: kind}
+}
+
+type causer interface {
+	Cause() error
+}
+
+// Is reports whether err, or any error it wraps, is or is marked as target.
+func Is(err, target error) bool {
+	for err != nil {
+		if err == target {
+			return true
+		}
+		switch e := err.(type) {
+		case *marked:
+			if e.kind == target {
+				return true
+			}
+		case *InvalidError:
+			if target == ErrInvalidInput {
+				return true
+			}
+		}
+		c, ok := err.(causer)
+		if !ok {
+			return false
+		}
+		err = c.Cause()
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package failure_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+)
+
+func TestIs(t *testing.T) {
+	raw := errors.New("sql error")
+	type args struct {
+		Err    error
+		Target error
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want bool
+	}{
+		{Name: "Nil", Args: args{Err: nil, Target: failure.ErrNotFound}, Want: false},
+		{Name: "Sentinel", Args: args{Err: failure.ErrCacheMiss, Target: failure.ErrCacheMiss}, Want: true},
+		{Name: "Other sentinel", Args: args{Err: failure.ErrCacheMiss, Target: failure.ErrNotFound}, Want: false},
+		{Name: "Wrapped sentinel", Args: args{Err: errors.Wrap(errors.Wrap(failure.ErrNotFound, "medallion"), "trips"), Target: failure.ErrNotFound}, Want: true},
+		{Name: "Marked", Args: args{Err: failure.Mark(raw, failure.ErrTimeout), Target: failure.ErrTimeout}, Want: true},
+		{Name: "Wrapped marked", Args: args{Err: errors.Wrap(failure.Mark(raw, failure.ErrUnavailable), "failed to query"), Target: failure.ErrUnavailable}, Want: true},
+		{Name: "Marked keeps the marked error", Args: args{Err: failure.Mark(raw, failure.ErrTimeout), Target: raw}, Want: true},
+		{Name: "Unmarked", Args: args{Err: errors.Wrap(raw, "failed to query"), Target: failure.ErrTimeout}, Want: false},
+		{Name: "Invalid field", Args: args{Err: errors.Wrap(failure.Invalid("limit", "too large"), "top"), Target: failure.ErrInvalidInput}, Want: true},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Want, failure.Is(tt.Args.Err, tt.Args.Target))
+		})
+	}
+}
+
+func TestMark(t *testing.T) {
+	assert.Nil(t, failure.Mark(nil, failure.ErrTimeout))
+	err := errors.Wrap(failure.Mark(errors.New("sql error"), failure.ErrTimeout), "failed to query")
+	assert.EqualError(t, err, "failed to query: sql error")
+	assert.EqualError(t, errors.Cause(err), "sql error")
+}
+
+func TestInvalid(t *testing.T) {
+	err := failure.Invalid("pickupdate", "not a date")
+	assert.EqualError(t, err, "invalid pickupdate: not a date")
+	assert.Equal(t, &failure.InvalidError{Field: "pickupdate", Reason: "not a date"}, err)
+}
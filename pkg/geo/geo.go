@@ -0,0 +1,110 @@
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6371008.8
+
+// Point represents a location in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Validate checks the point is within latitude and longitude bounds.
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if math.IsNaN(p.Lng) || p.Lng < -180 || p.Lng > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// Box represents a bounding box from its south west to its north east corner.
+// Boxes crossing the antimeridian are not supported.
+type Box struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// Validate checks the corners are valid points and ordered.
+func (b Box) Validate() error {
+	if err := b.Min.Validate(); err != nil {
+		return err
+	}
+	if err := b.Max.Validate(); err != nil {
+		return err
+	}
+	if b.Min.Lat > b.Max.Lat || b.Min.Lng > b.Max.Lng {
+		return errors.New("minimum corner must be south west of maximum corner")
+	}
+	return nil
+}
+
+// Contains reports whether the point is inside the box, edges included.
+func (b Box) Contains(p Point) bool {
+	return p.Lat >= b.Min.Lat && p.Lat <= b.Max.Lat && p.Lng >= b.Min.Lng && p.Lng <= b.Max.Lng
+}
+
+// Distance returns the great circle distance in meters between two points.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoxAround returns the smallest box containing every point within radius meters of center.
+func BoxAround(center Point, radius float64) Box {
+	dLat := degrees(radius / EarthRadius)
+	minLat := math.Max(-90, center.Lat-dLat)
+	maxLat := math.Min(90, center.Lat+dLat)
+	if minLat == -90 || maxLat == 90 {
+		return Box{Min: Point{Lat: minLat, Lng: -180}, Max: Point{Lat: maxLat, Lng: 180}}
+	}
+	dLng := degrees(math.Asin(math.Min(1, math.Sin(radius/EarthRadius)/math.Cos(radians(center.Lat)))))
+	return Box{
+		Min: Point{Lat: minLat, Lng: math.Max(-180, center.Lng-dLng)},
+		Max: Point{Lat: maxLat, Lng: math.Min(180, center.Lng+dLng)},
+	}
+}
+
+// Area is a bounding box, optionally narrowed to a radius around a center.
+type Area struct {
+	Box    Box
+	Center Point
+	Radius float64
+}
+
+// NewBoxArea creates an area covering the box.
+func NewBoxArea(box Box) Area {
+	return Area{Box: box}
+}
+
+// NewRadiusArea creates an area covering radius meters around center.
+func NewRadiusArea(center Point, radius float64) Area {
+	return Area{Box: BoxAround(center, radius), Center: center, Radius: radius}
+}
+
+// IsRadius reports whether the area is limited by a radius.
+func (a Area) IsRadius() bool {
+	return a.Radius > 0
+}
+
+// Contains reports whether the point is inside the area.
+func (a Area) Contains(p Point) bool {
+	if !a.Box.Contains(p) {
+		return false
+	}
+	return !a.IsRadius() || Distance(a.Center, p) <= a.Radius
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
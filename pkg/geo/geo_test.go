@@ -0,0 +1,128 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
+)
+
+var (
+	timesSquare   = geo.Point{Lat: 40.758, Lng: -73.9855}
+	empireState   = geo.Point{Lat: 40.7484, Lng: -73.9857}
+	jfk           = geo.Point{Lat: 40.6413, Lng: -73.7781}
+	midtownCorner = geo.Box{Min: geo.Point{Lat: 40.74, Lng: -74.0}, Max: geo.Point{Lat: 40.77, Lng: -73.97}}
+)
+
+func TestDistance(t *testing.T) {
+	type args struct {
+		A geo.Point
+		B geo.Point
+	}
+	type want struct {
+		Meters float64
+		Delta  float64
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{Name: "Same point", Args: args{A: timesSquare, B: timesSquare}, Want: want{Meters: 0, Delta: 0.001}},
+		{Name: "Times Square to Empire State", Args: args{A: timesSquare, B: empireState}, Want: want{Meters: 1068, Delta: 5}},
+		{Name: "Symmetric", Args: args{A: empireState, B: timesSquare}, Want: want{Meters: 1068, Delta: 5}},
+		{Name: "Times Square to JFK", Args: args{A: timesSquare, B: jfk}, Want: want{Meters: 21760, Delta: 50}},
+		{Name: "Quarter meridian", Args: args{A: geo.Point{Lat: 0, Lng: 0}, B: geo.Point{Lat: 90, Lng: 0}}, Want: want{Meters: 10007557, Delta: 10}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.InDelta(t, tt.Want.Meters, geo.Distance(tt.Args.A, tt.Args.B), tt.Want.Delta, "distance")
+		})
+	}
+}
+
+func TestBoxAround(t *testing.T) {
+	type args struct {
+		Center geo.Point
+		Radius float64
+	}
+	testTable := []struct {
+		Name string
+		Args args
+	}{
+		{Name: "Small radius", Args: args{Center: timesSquare, Radius: 500}},
+		{Name: "Large radius", Args: args{Center: timesSquare, Radius: 25000}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			box := geo.BoxAround(tt.Args.Center, tt.Args.Radius)
+			assert.NoError(t, box.Validate(), "valid box")
+			assert.True(t, box.Contains(tt.Args.Center), "contains center")
+			for _, bearing := range []geo.Point{{Lat: 1, Lng: 0}, {Lat: -1, Lng: 0}, {Lat: 0, Lng: 1}, {Lat: 0, Lng: -1}} {
+				edge := walk(tt.Args.Center, bearing, tt.Args.Radius*0.999)
+				assert.True(t, box.Contains(edge), "contains point inside radius %v", edge)
+			}
+		})
+	}
+}
+
+func TestArea_Contains(t *testing.T) {
+	type args struct {
+		Area  geo.Area
+		Point geo.Point
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want bool
+	}{
+		{Name: "Inside box", Args: args{Area: geo.NewBoxArea(midtownCorner), Point: timesSquare}, Want: true},
+		{Name: "Outside box", Args: args{Area: geo.NewBoxArea(midtownCorner), Point: jfk}},
+		{Name: "Inside radius", Args: args{Area: geo.NewRadiusArea(timesSquare, 1100), Point: empireState}, Want: true},
+		{Name: "Outside radius", Args: args{Area: geo.NewRadiusArea(timesSquare, 1000), Point: empireState}},
+		{Name: "Inside box corner but outside radius", Args: args{Area: geo.NewRadiusArea(timesSquare, 1000), Point: geo.Point{Lat: timesSquare.Lat + 0.0085, Lng: timesSquare.Lng + 0.0115}}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Want, tt.Args.Area.Contains(tt.Args.Point), "contains")
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testTable := []struct {
+		Name  string
+		Box   geo.Box
+		Error string
+	}{
+		{Name: "Valid", Box: midtownCorner},
+		{Name: "Invalid latitude", Box: geo.Box{Min: geo.Point{Lat: -91}, Max: geo.Point{Lat: 10, Lng: 10}}, Error: "latitude must be between -90 and 90"},
+		{Name: "Invalid longitude", Box: geo.Box{Min: geo.Point{}, Max: geo.Point{Lat: 10, Lng: 181}}, Error: "longitude must be between -180 and 180"},
+		{Name: "Swapped corners", Box: geo.Box{Min: midtownCorner.Max, Max: midtownCorner.Min}, Error: "minimum corner must be south west of maximum corner"},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.Box.Validate()
+			if tt.Error != "" {
+				assert.EqualError(t, err, tt.Error)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBoxAround_NearPole(t *testing.T) {
+	box := geo.BoxAround(geo.Point{Lat: 89.99, Lng: 10}, 5000)
+	assert.Equal(t, geo.Box{Min: geo.Point{Lat: box.Min.Lat, Lng: -180}, Max: geo.Point{Lat: 90, Lng: 180}}, box, "box spans all longitudes")
+}
+
+// walk moves meters from p along a unit direction, precise enough for short distances.
+func walk(p, dir geo.Point, meters float64) geo.Point {
+	metersPerDegree := geo.EarthRadius * math.Pi / 180
+	lat := p.Lat + dir.Lat*meters/metersPerDegree
+	lng := p.Lng + dir.Lng*meters/(metersPerDegree*math.Cos(p.Lat*math.Pi/180))
+	return geo.Point{Lat: lat, Lng: lng}
+}
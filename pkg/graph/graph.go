@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+const schema = `
+	schema {
+		query: Query
+	}
+
+	scalar Time
+
+	type Query {
+		# medallion looks up a single medallion.
+		medallion(id: String!): Medallion!
+		# medallions looks up medallions in the requested order, dropping duplicates. The maximum is 100 medallions.
+		medallions(ids: [String!]!): [Medallion!]!
+	}
+
+	# Basis selects whether trips are counted by their pick up or their drop off.
+	enum Basis {
+		PICKUP
+		DROPOFF
+	}
+
+	# Status tells medallions without trips apart from medallions never seen.
+	enum Status {
+		KNOWN
+		UNKNOWN
+	}
+
+	type Medallion {
+		id: String!
+		status: Status!
+		# trips counts all the trips, the trips on date or the trips in the inclusive from and to date range.
+		# The dates run from midnight to midnight in the tz time zone, defaulting to America/New_York.
+		trips(date: String, tz: String, from: String, to: String, basis: Basis = PICKUP, bypassCache: Boolean = false): Int!
+		# metrics sums up the trips of all dates or of the inclusive from and to date range.
+		# The dates run from midnight to midnight in the tz time zone, defaulting to America/New_York.
+		metrics(from: String, to: String, tz: String, basis: Basis = PICKUP, bypassCache: Boolean = false): Metrics!
+		# daily counts the trips of each date in the inclusive from and to date range, at most 366 days.
+		daily(from: String!, to: String!, basis: Basis = PICKUP, bypassCache: Boolean = false): [DailyCount!]!
+		# tripRecords lists at most first trip records, 1000 at most, following the after cursor.
+		tripRecords(from: String, to: String, basis: Basis = PICKUP, first: Int = 100, after: String): TripPage!
+	}
+
+	type Metrics {
+		trips: Int!
+		totalDistance: Float!
+		averageDistance: Float!
+		# totalDuration is the total trip time in seconds.
+		totalDuration: Int!
+		averageDuration: Float!
+		passengers: Int!
+		firstPickup: Time
+		lastPickup: Time
+	}
+
+	type DailyCount {
+		date: String!
+		trips: Int!
+	}
+
+	type TripPage {
+		trips: [Trip!]!
+		# nextCursor is only set while more trips are available.
+		nextCursor: String
+	}
+
+	type Trip {
+		pickupDatetime: Time!
+		dropoffDatetime: Time!
+		passengerCount: Int!
+		tripTimeInSecs: Int!
+		tripDistance: Float!
+		pickupLongitude: Float!
+		pickupLatitude: Float!
+		dropoffLongitude: Float!
+		dropoffLatitude: Float!
+	}
+`
+
+// Schema executes GraphQL queries with the service backing the REST API.
+type Schema struct {
+	schema  *graphql.Schema
+	tripSvc handler.Servicer
+}
+
+// NewSchema creates the schema, the zero bounds do not bound dates.
+// Up to validation.MaxMedallions list entries are resolved concurrently so that their fields join the same batch.
+func NewSchema(tripSvc handler.Servicer, bounds validation.Bounds) *Schema {
+	resolver := &Resolver{tripSvc: tripSvc, bounds: bounds}
+	return &Schema{schema: graphql.MustParseSchema(schema, resolver, graphql.MaxParallelism(validation.MaxMedallions)), tripSvc: tripSvc}
+}
+
+// Exec executes query with fresh loaders, so batches and their results are never shared between requests.
+func (s *Schema) Exec(ctx context.Context, query, operationName string, variables map[string]interface{}) *graphql.Response {
+	return s.schema.Exec(withLoaders(ctx, newLoaders(s.tripSvc)), query, operationName, variables)
+}
+
+// Error is a GraphQL error carrying the error code of the matching REST problem in its extensions.
+type Error struct {
+	Message       string
+	Code          string
+	InvalidParams []handler.InvalidParam
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Extensions lists the code and the invalid parameters of the error.
+func (e *Error) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.Code}
+	if len(e.InvalidParams) > 0 {
+		ext["invalidParams"] = e.InvalidParams
+	}
+	return ext
+}
+
+// errorOf maps err to an error with the code of the matching REST problem, unclassified errors are internal errors.
+func errorOf(err error) error {
+	_, code, message := handler.Classify(err)
+	return &Error{Message: message, Code: code}
+}
+
+// invalidArgsErr is an invalid input error detailing every invalid argument of a field.
+func invalidArgsErr(invalid handler.InvalidParams) error {
+	return &Error{Message: "invalid " + strings.Join(invalid.Names(), ", "), Code: handler.CodeInvalidInput, InvalidParams: invalid}
+}
@@ -0,0 +1,245 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+	"github.com/nikhil-github/api-cab-data/pkg/database"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/graph"
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/registry"
+	"github.com/nikhil-github/api-cab-data/pkg/service"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+const (
+	med1 = "D7D598CD99978BD012A87A76A7C891B7"
+	med2 = "5455D5FF2BD94D10B304A15D4B7F2735"
+	med3 = "0BD7C8F5BA12B88E0B67BED28BEA73D8"
+	med4 = "67EB082BFFE72095EAF18488BEA96050"
+)
+
+func TestSchema_Batching(t *testing.T) {
+	mockDB, m, err := sqlmock.New()
+	require.NoError(t, err, "Unable to create Sqlmock DB")
+	db := sqlx.NewDb(mockDB, "mysql")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"medallion", "trips"}).
+		AddRow(med1, 10).AddRow(med2, 3).AddRow(med3, 7).AddRow(med4, 1)
+	m.ExpectQuery(`medallion IN \(\?, \?, \?, \?\)\s+GROUP BY medallion`).WillReturnRows(rows)
+
+	c := cache.New(cache.Config{})
+	svc := service.New(database.NewQueryer(db, time.UTC, zap.NewNop()), time.UTC, registry.New(nil), c, c, zap.NewNop())
+	res := graph.NewSchema(svc, validation.Bounds{}).Exec(context.Background(), `{
+		medallions(ids: ["`+med1+`", "`+med2+`", "`+med3+`"]) { id trips status }
+		one: medallion(id: "`+med4+`") { trips }
+	}`, "", nil)
+
+	assert.NoError(t, m.ExpectationsWereMet(), "DB Expectations")
+	assert.Empty(t, res.Errors, "Errors")
+	assert.JSONEq(t, `{
+		"medallions": [
+			{"id": "`+med1+`", "trips": 10, "status": "KNOWN"},
+			{"id": "`+med2+`", "trips": 3, "status": "KNOWN"},
+			{"id": "`+med3+`", "trips": 7, "status": "KNOWN"}
+		],
+		"one": {"trips": 1}
+	}`, string(res.Data), "Data")
+}
+
+func TestSchema(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	bounds, err := validation.NewBounds("2013-12-01", "2013-12-31")
+	require.NoError(t, err)
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, newYork)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, newYork)
+	pickUp := time.Date(2013, 12, 31, 9, 30, 0, 0, time.UTC)
+	type args struct {
+		Query string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Data   string
+		Errors string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success, counts on a date in one batch",
+			Args: args{Query: `{ medallions(ids: ["` + med1 + `", "` + med2 + `"]) { id trips(date: "2013-12-31") } }`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionsOnPickUpDate", mock.Anything, sameMedallions(med1, med2), time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp, false).
+					Return([]output.Result{{Medallion: med1, Trips: 10}, {Medallion: med2, Trips: 3}}, nil).Once()
+			}},
+			Want: want{Data: `{"medallions": [{"id": "` + med1 + `", "trips": 10}, {"id": "` + med2 + `", "trips": 3}]}`},
+		},
+		{
+			Name: "Success, range counts and metrics by drop off in one batch each",
+			Args: args{Query: `{
+				a: medallion(id: "` + med1 + `") { trips(from: "2013-12-01", to: "2013-12-31", basis: DROPOFF, bypassCache: true) metrics(from: "2013-12-01", to: "2013-12-31", basis: DROPOFF) { trips totalDistance passengers firstPickup lastPickup } }
+				b: medallion(id: "` + med2 + `") { trips(from: "2013-12-01", to: "2013-12-31", basis: DROPOFF, bypassCache: true) metrics(from: "2013-12-01", to: "2013-12-31", basis: DROPOFF) { trips totalDistance passengers firstPickup lastPickup } }
+			}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionsInDateRange", mock.Anything, sameMedallions(med1, med2), from, to, output.DropOff, true).
+					Return([]output.Result{{Medallion: med1, Trips: 10}, {Medallion: med2, Trips: 0}}, nil).Once()
+				m.On("Metrics", mock.Anything, sameMedallions(med1, med2), from, to, output.DropOff, false).
+					Return([]output.Metrics{{Medallion: med1, Trips: 10, TotalDistance: 20.5, Passengers: 12, FirstPickUp: &pickUp, LastPickUp: &pickUp}, {Medallion: med2}}, nil).Once()
+			}},
+			Want: want{Data: `{
+				"a": {"trips": 10, "metrics": {"trips": 10, "totalDistance": 20.5, "passengers": 12, "firstPickup": "2013-12-31T09:30:00Z", "lastPickup": "2013-12-31T09:30:00Z"}},
+				"b": {"trips": 0, "metrics": {"trips": 0, "totalDistance": 0, "passengers": 0, "firstPickup": null, "lastPickup": null}}
+			}`},
+		},
+		{
+			Name: "Success, daily counts and trip records",
+			Args: args{Query: `{ medallion(id: "` + med1 + `") { daily(from: "2013-12-30", to: "2013-12-31") { date trips } tripRecords(first: 1) { trips { pickupDatetime passengerCount tripDistance } nextCursor } } }`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionPerDay", mock.Anything, med1, time.Date(2013, 12, 30, 0, 0, 0, 0, time.UTC), time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC), output.PickUp, false).
+					Return([]output.DailyResult{{Date: "2013-12-30", Trips: 0}, {Date: "2013-12-31", Trips: 4}}, nil)
+				m.On("Trips", mock.Anything, med1, time.Time{}, time.Time{}, output.PickUp, (*output.Cursor)(nil), 1).
+					Return(output.TripPage{Trips: []output.Trip{{Medallion: med1, PickUpDateTime: pickUp, PassengerCount: 2, TripDistance: 1.5}}, NextCursor: "next"}, nil)
+			}},
+			Want: want{Data: `{"medallion": {
+				"daily": [{"date": "2013-12-30", "trips": 0}, {"date": "2013-12-31", "trips": 4}],
+				"tripRecords": {"trips": [{"pickupDatetime": "2013-12-31T09:30:00Z", "passengerCount": 2, "tripDistance": 1.5}], "nextCursor": "next"}
+			}}`},
+		},
+		{
+			Name:   "Failure - Invalid medallion",
+			Args:   args{Query: `{ medallion(id: "YYYY") { id } }`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want: want{Data: `null`, Errors: `[{"message": "invalid id", "extensions": {"code": "invalid_input", "invalidParams": [
+				{"name": "id", "reason": "YYYY is not a 32 character hex medallion"}
+			]}}]`},
+		},
+		{
+			Name:   "Failure - Every invalid argument is listed",
+			Args:   args{Query: `{ medallion(id: "` + med1 + `") { id trips(date: "2013-12-31", from: "2013-12-01", to: "2013-12-31", tz: "Mars/Olympus") daily(from: "2014-01-01", to: "2014-01-02") { date } } }`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want: want{Data: `null`, Errors: `[
+				{"message": "invalid date, tz", "extensions": {"code": "invalid_input", "invalidParams": [
+					{"name": "date", "reason": "date can not be combined with from and to"},
+					{"name": "tz", "reason": "invalid tz value"}
+				]}},
+				{"message": "invalid from, to", "extensions": {"code": "invalid_input", "invalidParams": [
+					{"name": "from", "reason": "date must be between 2013-12-01 and 2013-12-31"},
+					{"name": "to", "reason": "date must be between 2013-12-01 and 2013-12-31"}
+				]}}
+			]`},
+		},
+		{
+			Name: "Failure - Query timeout",
+			Args: args{Query: `{ medallion(id: "` + med1 + `") { trips } }`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallion", mock.Anything, []string{med1}, false).
+					Return([]output.Result(nil), errors.Wrap(failure.Mark(context.DeadlineExceeded, failure.ErrTimeout), "failed to query"))
+			}},
+			Want: want{Data: `null`, Errors: `[{"message": "query timeout", "extensions": {"code": "query_timeout"}}]`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			res := graph.NewSchema(&m, bounds).Exec(context.Background(), tt.Args.Query, "", nil)
+			m.AssertExpectations(t)
+			assert.JSONEq(t, tt.Want.Data, string(res.Data), "Data")
+			if tt.Want.Errors == "" {
+				assert.Empty(t, res.Errors, "Errors")
+				return
+			}
+			assert.JSONEq(t, tt.Want.Errors, errorsOf(t, res.Errors), "Errors")
+		})
+	}
+}
+
+// errorsOf drops the locations and paths of errors, sorted by message as fields resolve concurrently.
+func errorsOf(t *testing.T, errs interface{}) string {
+	b, err := json.Marshal(errs)
+	require.NoError(t, err)
+	var list []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions,omitempty"`
+	}
+	require.NoError(t, json.Unmarshal(b, &list))
+	sort.Slice(list, func(i, j int) bool { return list[i].Message < list[j].Message })
+	b, err = json.Marshal(list)
+	require.NoError(t, err)
+	return string(b)
+}
+
+// sameMedallions matches the medallions of a batch in any order, keys join a batch as their fields resolve.
+func sameMedallions(want ...string) interface{} {
+	return mock.MatchedBy(func(got []string) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		sorted := append([]string(nil), got...)
+		sort.Strings(sorted)
+		expected := append([]string(nil), want...)
+		sort.Strings(expected)
+		for i := range sorted {
+			if sorted[i] != expected[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// mockTripSvc mocks the methods of handler.Servicer resolved by the schema.
+type mockTripSvc struct {
+	mock.Mock
+	handler.Servicer
+}
+
+func (m *mockTripSvc) TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, pickUpDate, basis, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) TripsByMedallion(ctx context.Context, medallions []string, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, from, to, basis, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.DailyResult, error) {
+	args := m.Called(ctx, medallion, from, to, basis, byPassCache)
+	return args.Get(0).([]output.DailyResult), args.Error(1)
+}
+
+func (m *mockTripSvc) Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Metrics, error) {
+	args := m.Called(ctx, medallions, from, to, basis, byPassCache)
+	return args.Get(0).([]output.Metrics), args.Error(1)
+}
+
+func (m *mockTripSvc) Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) (output.TripPage, error) {
+	args := m.Called(ctx, medallion, from, to, basis, after, limit)
+	return args.Get(0).(output.TripPage), args.Error(1)
+}
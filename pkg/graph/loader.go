@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/dataloader"
+
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+// dateLayout is the format of the dates of a loader key.
+const dateLayout = "2006-01-02"
+
+// loaders batch the medallions requested by the fields of a query, with a loader per count and its arguments.
+// Each batch is answered by a single service call, a single IN query for the medallions missing from cache.
+type loaders struct {
+	tripSvc handler.Servicer
+	mu      sync.Mutex
+	byKey   map[string]*dataloader.Loader
+}
+
+func newLoaders(tripSvc handler.Servicer) *loaders {
+	return &loaders{tripSvc: tripSvc, byKey: make(map[string]*dataloader.Loader)}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// batchFunc loads the values of medallions keyed by medallion.
+type batchFunc func(ctx context.Context, medallions []string) (map[string]interface{}, error)
+
+// load loads the value of medallion with the loader of key, fn creates the loader on first use.
+func (l *loaders) load(ctx context.Context, key, medallion string, fn batchFunc) (interface{}, error) {
+	l.mu.Lock()
+	loader, ok := l.byKey[key]
+	if !ok {
+		loader = dataloader.NewBatchedLoader(batch(fn), dataloader.WithBatchCapacity(validation.MaxMedallions))
+		l.byKey[key] = loader
+	}
+	l.mu.Unlock()
+	return loader.Load(ctx, dataloader.StringKey(medallion))()
+}
+
+// batch answers every key of a batch with the value of its medallion or the error of fn.
+func batch(fn batchFunc) dataloader.BatchFunc {
+	return func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		vals, err := fn(ctx, keys.Keys())
+		results := make([]*dataloader.Result, 0, len(keys))
+		for _, key := range keys {
+			results = append(results, &dataloader.Result{Data: vals[key.String()], Error: err})
+		}
+		return results
+	}
+}
+
+// total loads all the trips of medallion.
+func (l *loaders) total(ctx context.Context, medallion string, byPassCache bool) (output.Result, error) {
+	val, err := l.load(ctx, fmt.Sprintf("total/%t", byPassCache), medallion, func(ctx context.Context, medallions []string) (map[string]interface{}, error) {
+		return results(l.tripSvc.TripsByMedallion(ctx, medallions, byPassCache))
+	})
+	res, _ := val.(output.Result)
+	return res, err
+}
+
+// onDate loads the trips of medallion on the date starting at pickUpDate.
+func (l *loaders) onDate(ctx context.Context, medallion string, pickUpDate time.Time, basis output.Basis, byPassCache bool) (output.Result, error) {
+	key := fmt.Sprintf("date/%s/%s/%t", pickUpDate.Format(time.RFC3339), basis, byPassCache)
+	val, err := l.load(ctx, key, medallion, func(ctx context.Context, medallions []string) (map[string]interface{}, error) {
+		return results(l.tripSvc.TripsByMedallionsOnPickUpDate(ctx, medallions, pickUpDate, basis, byPassCache))
+	})
+	res, _ := val.(output.Result)
+	return res, err
+}
+
+// inRange loads the trips of medallion in the inclusive date range in the time zone of from.
+func (l *loaders) inRange(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, byPassCache bool) (output.Result, error) {
+	key := fmt.Sprintf("range/%s/%s/%s/%s/%t", from.Format(dateLayout), to.Format(dateLayout), from.Location(), basis, byPassCache)
+	val, err := l.load(ctx, key, medallion, func(ctx context.Context, medallions []string) (map[string]interface{}, error) {
+		return results(l.tripSvc.TripsByMedallionsInDateRange(ctx, medallions, from, to, basis, byPassCache))
+	})
+	res, _ := val.(output.Result)
+	return res, err
+}
+
+// metrics loads the trip figures of medallion in the time zone of from, zero from and to dates sum up all dates.
+func (l *loaders) metrics(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, byPassCache bool) (output.Metrics, error) {
+	key := fmt.Sprintf("metrics/%s/%s/%s/%s/%t", from.Format(dateLayout), to.Format(dateLayout), from.Location(), basis, byPassCache)
+	val, err := l.load(ctx, key, medallion, func(ctx context.Context, medallions []string) (map[string]interface{}, error) {
+		metrics, err := l.tripSvc.Metrics(ctx, medallions, from, to, basis, byPassCache)
+		if err != nil {
+			return nil, err
+		}
+		vals := make(map[string]interface{}, len(metrics))
+		for _, m := range metrics {
+			vals[m.Medallion] = m
+		}
+		return vals, nil
+	})
+	m, _ := val.(output.Metrics)
+	return m, err
+}
+
+// results keys the trip counts by medallion.
+func results(res []output.Result, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	vals := make(map[string]interface{}, len(res))
+	for _, r := range res {
+		vals[r.Medallion] = r
+	}
+	return vals, nil
+}
@@ -0,0 +1,387 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+// maxFirst is the maximum number of trip records of a page.
+const maxFirst = 1000
+
+// Resolver resolves the queries of the schema.
+type Resolver struct {
+	tripSvc handler.Servicer
+	bounds  validation.Bounds
+}
+
+// Medallion resolves a single medallion.
+func (r *Resolver) Medallion(ctx context.Context, args struct{ ID string }) (*MedallionResolver, error) {
+	medallion, err := validation.Medallion(args.ID)
+	if err != nil {
+		var invalid handler.InvalidParams
+		invalid.AddAs("id", err)
+		return nil, invalidArgsErr(invalid)
+	}
+	return &MedallionResolver{medallion: medallion, tripSvc: r.tripSvc, bounds: r.bounds}, nil
+}
+
+// Medallions resolves medallions in the requested order, dropping duplicates.
+func (r *Resolver) Medallions(ctx context.Context, args struct{ IDs []string }) ([]*MedallionResolver, error) {
+	medallions, err := validation.Medallions(args.IDs, validation.MaxMedallions)
+	if err != nil {
+		var invalid handler.InvalidParams
+		invalid.AddAs("ids", err)
+		return nil, invalidArgsErr(invalid)
+	}
+	resolvers := make([]*MedallionResolver, 0, len(medallions))
+	for _, medallion := range medallions {
+		resolvers = append(resolvers, &MedallionResolver{medallion: medallion, tripSvc: r.tripSvc, bounds: r.bounds})
+	}
+	return resolvers, nil
+}
+
+// MedallionResolver resolves the trips of a medallion.
+// Counts and metrics are loaded in batches with the same fields of the other medallions of the query.
+type MedallionResolver struct {
+	medallion string
+	tripSvc   handler.Servicer
+	bounds    validation.Bounds
+}
+
+// ID is the medallion.
+func (r *MedallionResolver) ID() string {
+	return r.medallion
+}
+
+// Status tells whether the medallion has trips on record.
+func (r *MedallionResolver) Status(ctx context.Context) (string, error) {
+	res, err := loadersOf(ctx).total(ctx, r.medallion, false)
+	if err != nil {
+		return "", errorOf(err)
+	}
+	if res.Status == output.Unknown {
+		return "UNKNOWN", nil
+	}
+	return "KNOWN", nil
+}
+
+// Trips counts all the trips, the trips on date or the trips in the inclusive from and to date range.
+func (r *MedallionResolver) Trips(ctx context.Context, args struct {
+	Date        *string
+	Tz          *string
+	From        *string
+	To          *string
+	Basis       string
+	BypassCache bool
+}) (int32, error) {
+	var invalid handler.InvalidParams
+	var date time.Time
+	var err error
+	if args.Date != nil {
+		if args.From != nil || args.To != nil {
+			invalid.Add("date", "date can not be combined with from and to")
+		} else if date, err = validation.Date(*args.Date); err != nil {
+			invalid.Add("date", "invalid date")
+		} else {
+			invalid.Check(r.bounds.Date("date", date))
+		}
+	}
+
+	zone, err := validation.Zone(valueOf(args.Tz))
+	if err != nil {
+		invalid.Add("tz", "invalid tz value")
+	}
+
+	from, to, err := validation.Dates(valueOf(args.From), valueOf(args.To))
+	if err != nil {
+		invalid.AddErr("from", err)
+	} else {
+		invalid.Check(r.bounds.Date("from", from))
+		invalid.Check(r.bounds.Date("to", to))
+	}
+
+	basis, err := validation.Basis(strings.ToLower(args.Basis))
+	if err != nil {
+		invalid.Add("basis", "invalid basis value")
+	}
+
+	if len(invalid) > 0 {
+		return 0, invalidArgsErr(invalid)
+	}
+
+	var res output.Result
+	switch {
+	case !date.IsZero():
+		res, err = loadersOf(ctx).onDate(ctx, r.medallion, validation.InZone(date, zone), basis, args.BypassCache)
+	case !from.IsZero():
+		res, err = loadersOf(ctx).inRange(ctx, r.medallion, validation.InZone(from, zone), validation.InZone(to, zone), basis, args.BypassCache)
+	default:
+		res, err = loadersOf(ctx).total(ctx, r.medallion, args.BypassCache)
+	}
+	if err != nil {
+		return 0, errorOf(err)
+	}
+	return int32(res.Trips), nil
+}
+
+// Metrics sums up the trips of all dates or of the inclusive from and to date range.
+func (r *MedallionResolver) Metrics(ctx context.Context, args struct {
+	From        *string
+	To          *string
+	Tz          *string
+	Basis       string
+	BypassCache bool
+}) (*MetricsResolver, error) {
+	var invalid handler.InvalidParams
+	from, to, err := validation.Dates(valueOf(args.From), valueOf(args.To))
+	if err != nil {
+		invalid.AddErr("from", err)
+	} else {
+		invalid.Check(r.bounds.Date("from", from))
+		invalid.Check(r.bounds.Date("to", to))
+	}
+
+	zone, err := validation.Zone(valueOf(args.Tz))
+	if err != nil {
+		invalid.Add("tz", "invalid tz value")
+	}
+
+	basis, err := validation.Basis(strings.ToLower(args.Basis))
+	if err != nil {
+		invalid.Add("basis", "invalid basis value")
+	}
+
+	if len(invalid) > 0 {
+		return nil, invalidArgsErr(invalid)
+	}
+
+	if !from.IsZero() {
+		from, to = validation.InZone(from, zone), validation.InZone(to, zone)
+	}
+
+	m, err := loadersOf(ctx).metrics(ctx, r.medallion, from, to, basis, args.BypassCache)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	return &MetricsResolver{m: m}, nil
+}
+
+// Daily counts the trips of each date in the inclusive from and to date range.
+func (r *MedallionResolver) Daily(ctx context.Context, args struct {
+	From        string
+	To          string
+	Basis       string
+	BypassCache bool
+}) ([]*DailyCountResolver, error) {
+	var invalid handler.InvalidParams
+	from, to, err := validation.Dates(args.From, args.To)
+	if err != nil {
+		invalid.AddErr("from", err)
+	} else if from.IsZero() {
+		invalid.Add("from", "invalid from date")
+	} else if to.Sub(from) >= validation.MaxDays*24*time.Hour {
+		invalid.Add("to", "max date range is 366 days")
+	} else {
+		invalid.Check(r.bounds.Date("from", from))
+		invalid.Check(r.bounds.Date("to", to))
+	}
+
+	basis, err := validation.Basis(strings.ToLower(args.Basis))
+	if err != nil {
+		invalid.Add("basis", "invalid basis value")
+	}
+
+	if len(invalid) > 0 {
+		return nil, invalidArgsErr(invalid)
+	}
+
+	results, err := r.tripSvc.TripsByMedallionPerDay(ctx, r.medallion, from, to, basis, args.BypassCache)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	resolvers := make([]*DailyCountResolver, 0, len(results))
+	for _, res := range results {
+		resolvers = append(resolvers, &DailyCountResolver{res: res})
+	}
+	return resolvers, nil
+}
+
+// TripRecords lists a page of trip records following the after cursor.
+func (r *MedallionResolver) TripRecords(ctx context.Context, args struct {
+	From  *string
+	To    *string
+	Basis string
+	First int32
+	After *string
+}) (*TripPageResolver, error) {
+	var invalid handler.InvalidParams
+	var from, to time.Time
+	var err error
+	if args.From != nil {
+		if from, err = validation.Date(*args.From); err != nil {
+			invalid.Add("from", "invalid from date")
+		} else {
+			invalid.Check(r.bounds.Date("from", from))
+		}
+	}
+	if args.To != nil {
+		if to, err = validation.Date(*args.To); err != nil {
+			invalid.Add("to", "invalid to date")
+		} else {
+			invalid.Check(r.bounds.Date("to", to))
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		invalid.Add("from", "from date is after to date")
+	}
+
+	limit := int(args.First)
+	if limit < 1 || limit > maxFirst {
+		invalid.Add("first", fmt.Sprintf("first must be between 1 and %d", maxFirst))
+	}
+
+	var cursor *output.Cursor
+	if args.After != nil {
+		c, err := output.DecodeCursor(*args.After)
+		if err != nil {
+			invalid.Add("after", "invalid cursor")
+		}
+		cursor = &c
+	}
+
+	basis, err := validation.Basis(strings.ToLower(args.Basis))
+	if err != nil {
+		invalid.Add("basis", "invalid basis value")
+	}
+
+	if len(invalid) > 0 {
+		return nil, invalidArgsErr(invalid)
+	}
+
+	page, err := r.tripSvc.Trips(ctx, r.medallion, from, to, basis, cursor, limit)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	return &TripPageResolver{page: page}, nil
+}
+
+// MetricsResolver resolves the trip figures of a medallion.
+type MetricsResolver struct {
+	m output.Metrics
+}
+
+// Trips is the number of trips.
+func (r *MetricsResolver) Trips() int32 { return int32(r.m.Trips) }
+
+// TotalDistance is the total trip distance.
+func (r *MetricsResolver) TotalDistance() float64 { return r.m.TotalDistance }
+
+// AverageDistance is the average trip distance.
+func (r *MetricsResolver) AverageDistance() float64 { return r.m.AverageDistance }
+
+// TotalDuration is the total trip time in seconds.
+func (r *MetricsResolver) TotalDuration() int32 { return int32(r.m.TotalDuration) }
+
+// AverageDuration is the average trip time in seconds.
+func (r *MetricsResolver) AverageDuration() float64 { return r.m.AverageDuration }
+
+// Passengers is the total number of passengers.
+func (r *MetricsResolver) Passengers() int32 { return int32(r.m.Passengers) }
+
+// FirstPickup is the first pick up time, nil without trips.
+func (r *MetricsResolver) FirstPickup() *graphql.Time { return toTime(r.m.FirstPickUp) }
+
+// LastPickup is the last pick up time, nil without trips.
+func (r *MetricsResolver) LastPickup() *graphql.Time { return toTime(r.m.LastPickUp) }
+
+// DailyCountResolver resolves the trips of a single date.
+type DailyCountResolver struct {
+	res output.DailyResult
+}
+
+// Date is the date formatted as YYYY-MM-DD.
+func (r *DailyCountResolver) Date() string { return r.res.Date }
+
+// Trips is the number of trips on the date.
+func (r *DailyCountResolver) Trips() int32 { return int32(r.res.Trips) }
+
+// TripPageResolver resolves a page of trip records.
+type TripPageResolver struct {
+	page output.TripPage
+}
+
+// Trips are the trip records of the page.
+func (r *TripPageResolver) Trips() []*TripResolver {
+	resolvers := make([]*TripResolver, 0, len(r.page.Trips))
+	for _, trip := range r.page.Trips {
+		resolvers = append(resolvers, &TripResolver{trip: trip})
+	}
+	return resolvers
+}
+
+// NextCursor is the cursor of the next page, nil on the last page.
+func (r *TripPageResolver) NextCursor() *string {
+	if len(r.page.NextCursor) == 0 {
+		return nil
+	}
+	return &r.page.NextCursor
+}
+
+// TripResolver resolves a single trip record.
+type TripResolver struct {
+	trip output.Trip
+}
+
+// PickupDatetime is the pick up time.
+func (r *TripResolver) PickupDatetime() graphql.Time {
+	return graphql.Time{Time: r.trip.PickUpDateTime}
+}
+
+// DropoffDatetime is the drop off time.
+func (r *TripResolver) DropoffDatetime() graphql.Time {
+	return graphql.Time{Time: r.trip.DropOffDateTime}
+}
+
+// PassengerCount is the number of passengers.
+func (r *TripResolver) PassengerCount() int32 { return int32(r.trip.PassengerCount) }
+
+// TripTimeInSecs is the trip time in seconds.
+func (r *TripResolver) TripTimeInSecs() int32 { return int32(r.trip.TripTimeInSecs) }
+
+// TripDistance is the trip distance.
+func (r *TripResolver) TripDistance() float64 { return r.trip.TripDistance }
+
+// PickupLongitude is the longitude of the pick up.
+func (r *TripResolver) PickupLongitude() float64 { return r.trip.PickUpLongitude }
+
+// PickupLatitude is the latitude of the pick up.
+func (r *TripResolver) PickupLatitude() float64 { return r.trip.PickUpLatitude }
+
+// DropoffLongitude is the longitude of the drop off.
+func (r *TripResolver) DropoffLongitude() float64 { return r.trip.DropOffLongitude }
+
+// DropoffLatitude is the latitude of the drop off.
+func (r *TripResolver) DropoffLatitude() float64 { return r.trip.DropOffLatitude }
+
+// valueOf returns the value of an optional argument, empty when it is not supplied.
+func valueOf(val *string) string {
+	if val == nil {
+		return ""
+	}
+	return *val
+}
+
+func toTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
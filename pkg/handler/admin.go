@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// bearerPrefix prefixes the token of the Authorization header.
+const bearerPrefix = "Bearer "
+
+// Admin only lets requests authorized with the admin token through to next.
+// Requests without the token get a 401 problem, every request gets a 403 problem while no token is configured.
+func Admin(logger *zap.Logger, token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(token) == 0 {
+			logger.Warn("admin request while no admin token is configured", zap.String("path", r.URL.Path))
+			responseProblem(w, NewProblem(r, http.StatusForbidden, CodeForbidden, "admin endpoints are disabled"))
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, bearerPrefix) || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, bearerPrefix)), []byte(token)) != 1 {
+			logger.Warn("unauthorized admin request", zap.String("path", r.URL.Path))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			responseProblem(w, NewProblem(r, http.StatusUnauthorized, CodeUnauthorized, "admin token required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
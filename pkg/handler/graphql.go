@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+)
+
+// Executor provides method to execute GraphQL queries.
+type Executor interface {
+	Exec(ctx context.Context, query, operationName string, variables map[string]interface{}) *graphql.Response
+}
+
+// GraphQLRequest represents a GraphQL query in a request body.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL executes the GraphQL query of the request body.
+// Errors of executed queries are reported in the errors of the response, status 200, as GraphQL clients expect.
+func GraphQL(logger *zap.Logger, executor Executor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		var q GraphQLRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQueryBytes)).Decode(&q); err != nil {
+			logger.Error("Error: query is not valid", zap.Error(err))
+			invalid.Add("body", "invalid query")
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		if len(strings.TrimSpace(q.Query)) == 0 {
+			invalid.Add("query", "missing query")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		res := executor.Exec(r.Context(), q.Query, q.OperationName, q.Variables)
+		if len(res.Errors) > 0 {
+			logger.Error("Error: executing query", zap.Any("errors", res.Errors))
+		}
+		responseOK(w, enc, res)
+	}
+}
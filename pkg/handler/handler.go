@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -12,45 +15,153 @@ import (
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
 	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/registry"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
 )
 
+const (
+	// maxLicenses is the maximum number of hack licenses per request.
+	maxLicenses = 100
+	// maxQueryBytes is the maximum size of a bulk query body.
+	maxQueryBytes = 1 << 20
+	// defaultLimit is the number of trip records per page when limit is not supplied.
+	defaultLimit = 100
+	// maxLimit is the maximum number of trip records per page.
+	maxLimit = 1000
+	// defaultTopLimit is the number of ranked medallions when limit is not supplied.
+	defaultTopLimit = 10
+	// maxTopLimit is the maximum number of ranked medallions.
+	maxTopLimit = 100
+	// maxRadius is the largest radius in meters of an area search.
+	maxRadius = 50000
+	// defaultMedallionLimit is the number of listed medallions per page when limit is not supplied.
+	defaultMedallionLimit = 100
+	// maxMedallionLimit is the maximum number of listed medallions per page.
+	maxMedallionLimit = 1000
+	// defaultEntries is the number of listed cache entries when limit is not supplied.
+	defaultEntries = 100
+	// maxEntries is the maximum number of listed cache entries.
+	maxEntries = 1000
+)
+
+// Query represents the body of a bulk trips query.
+// From and To are optional but must be supplied together.
+type Query struct {
+	Medallions  []string `json:"medallions"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Basis       string   `json:"basis"`
+	ByPassCache bool     `json:"bypasscache"`
+}
+
 // Servicer provides method to get count of trips.
 type Servicer interface {
-	TripsByMedallionsOnPickUpDate(ctx context.Context, medallions string, pickUpDate time.Time, byPassCache bool) (output.Result, error)
+	TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error)
 	TripsByMedallion(ctx context.Context, medallions []string, byPassCache bool) ([]output.Result, error)
+	TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error)
+	TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.DailyResult, error)
+	PickUpHistogram(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (output.Histogram, error)
+	StreamTripsByMedallions(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool, fn func([]output.Result) error) error
+	Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) (output.TripPage, error)
+	TopMedallions(ctx context.Context, from, to time.Time, basis output.Basis, limit int, byPassCache bool) ([]output.RankedResult, error)
+	TripsByDriver(ctx context.Context, licenses []string, byPassCache bool) ([]output.DriverResult, error)
+	TripsByDriverOnPickUpDate(ctx context.Context, license string, pickUpDate time.Time, basis output.Basis, byPassCache bool) (output.DriverResult, error)
+	TripsInArea(ctx context.Context, area geo.Area, from, to time.Time, basis output.Basis, medallions []string, perMedallion bool) (output.AreaResult, error)
+	Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Metrics, error)
 }
 
-// Clearer provides method to clear cache.
+// Lister provides method to list the known medallions.
+type Lister interface {
+	Medallions(ctx context.Context, opts registry.Options) output.MedallionPage
+}
+
+// Clearer provides methods to clear cache or invalidate selected entries, both return how many entries were evicted.
 type Clearer interface {
-	Clear(ctx context.Context)
+	Clear(ctx context.Context) int
+	Invalidate(ctx context.Context, sel cache.Selector) int
+}
+
+// Inspector provides methods to report cache statistics and list cache entries.
+type Inspector interface {
+	Stats(ctx context.Context) output.CacheStats
+	Entries(ctx context.Context, prefix string, limit int) []output.CacheEntry
 }
 
-// TripsByMedallionsOnPickUpDate get number of trips by medallion on pick up date.
-func TripsByMedallionsOnPickUpDate(logger *zap.Logger, tripSvc Servicer) http.HandlerFunc {
+// TripsByMedallionsOnPickUpDate get number of trips by medallions on pick up date.
+// The pick up date runs from midnight to midnight in the tz time zone.
+// A single medallion is answered with a single result for backward compatibility.
+func TripsByMedallionsOnPickUpDate(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		enc := json.NewEncoder(w)
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-		medallion := mux.Vars(r)["medallion"]
+		var invalid InvalidParams
+		medallions, err := validation.Medallions(strings.Split(mux.Vars(r)["medallions"], ","), validation.MaxMedallions)
+		if err != nil {
+			invalid.AddErr("medallions", err)
+		}
+
 		pickupDate, err := parsePickUpDate(r)
 		if err != nil {
-			logger.Error("Error: pickupdate is not a valid", zap.Error(err))
-			responseBadRequest(w, enc, "invalid pick up date")
-			return
+			invalid.Add("pickupdate", "invalid pick up date")
+		} else {
+			invalid.Check(bounds.Date("pickupdate", pickupDate))
+		}
+
+		zone, err := parseZone(r)
+		if err != nil {
+			invalid.Add("tz", "invalid tz value")
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
 		}
 
 		byPassCache, err := parseByPassCache(r)
 		if err != nil {
-			logger.Error("ByPassCache is not a valid value", zap.Bool("byPassCache", byPassCache))
-			responseBadRequest(w, enc, "invalid bypasscache value")
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		fields, err := parseFields(r)
+		if err != nil {
+			invalid.Add("fields", "invalid fields")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		if len(fields) > 0 {
+			date := validation.InZone(pickupDate, zone)
+			metrics, err := tripSvc.Metrics(r.Context(), medallions, date, date, basis, byPassCache)
+			if err != nil {
+				logger.Error("Error: finding metrics", zap.Error(err))
+				responseFailure(w, r, err)
+				return
+			}
+			if len(medallions) == 1 && len(metrics) == 1 {
+				responseOK(w, enc, output.NewExtendedResult(metrics[0], fields))
+				return
+			}
+			responseOK(w, enc, extend(metrics, fields))
 			return
 		}
 
-		results, err := tripSvc.TripsByMedallionsOnPickUpDate(r.Context(), medallion, pickupDate, byPassCache)
+		results, err := tripSvc.TripsByMedallionsOnPickUpDate(r.Context(), medallions, validation.InZone(pickupDate, zone), basis, byPassCache)
 		if err != nil {
 			logger.Error("Error: counting trips", zap.Error(err))
-			serverError(w, enc, "service failure")
+			responseFailure(w, r, err)
+			return
+		}
+		if len(medallions) == 1 && len(results) == 1 {
+			responseOK(w, enc, results[0])
 			return
 		}
 		responseOK(w, enc, results)
@@ -63,42 +174,701 @@ func TripsByMedallion(logger *zap.Logger, tripSvc Servicer) http.HandlerFunc {
 		enc := json.NewEncoder(w)
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-		medallions := strings.Split(mux.Vars(r)["medallions"], ",")
-		if len(medallions) == 0 {
-			logger.Error("medallions missing")
-			responseBadRequest(w, enc, "missing medallions")
+		var invalid InvalidParams
+		medallions, err := validation.Medallions(strings.Split(mux.Vars(r)["medallions"], ","), validation.MaxMedallions)
+		if err != nil {
+			invalid.AddErr("medallions", err)
 		}
 
 		byPassCache, err := parseByPassCache(r)
 		if err != nil {
-			logger.Error("ByPassCache is not a valid", zap.Bool("byPassCache", byPassCache))
-			responseBadRequest(w, enc, "invalid bypasscache")
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		fields, err := parseFields(r)
+		if err != nil {
+			invalid.Add("fields", "invalid fields")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
 			return
 		}
 
-		if len(medallions) > 100 {
-			logger.Error("Max number of medallions is 100")
-			responseBadRequest(w, enc, "max number of medallions is 100")
+		if len(fields) > 0 {
+			metrics, err := tripSvc.Metrics(r.Context(), medallions, time.Time{}, time.Time{}, output.PickUp, byPassCache)
+			if err != nil {
+				logger.Error("Error: finding metrics", zap.Error(err))
+				responseFailure(w, r, err)
+				return
+			}
+			responseOK(w, enc, extend(metrics, fields))
 			return
 		}
 
 		results, err := tripSvc.TripsByMedallion(r.Context(), medallions, byPassCache)
 		if err != nil {
 			logger.Error("Error: counting trips", zap.Error(err))
-			serverError(w, enc, "service failure")
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, results)
+	}
+}
+
+// TripsByMedallionsInDateRange query for number of trips per medallion with pick up date in the inclusive range.
+// The dates run from midnight to midnight in the tz time zone, for the counts and the metrics fields alike.
+func TripsByMedallionsInDateRange(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		medallions, err := validation.Medallions(strings.Split(mux.Vars(r)["medallions"], ","), validation.MaxMedallions)
+		if err != nil {
+			invalid.AddErr("medallions", err)
+		}
+
+		from, to, err := parseDateRange(r)
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		zone, err := parseZone(r)
+		if err != nil {
+			invalid.Add("tz", "invalid tz value")
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		byPassCache, err := parseByPassCache(r)
+		if err != nil {
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		fields, err := parseFields(r)
+		if err != nil {
+			invalid.Add("fields", "invalid fields")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		from, to = validation.InZone(from, zone), validation.InZone(to, zone)
+		if len(fields) > 0 {
+			metrics, err := tripSvc.Metrics(r.Context(), medallions, from, to, basis, byPassCache)
+			if err != nil {
+				logger.Error("Error: finding metrics", zap.Error(err))
+				responseFailure(w, r, err)
+				return
+			}
+			responseOK(w, enc, extend(metrics, fields))
+			return
+		}
+
+		results, err := tripSvc.TripsByMedallionsInDateRange(r.Context(), medallions, from, to, basis, byPassCache)
+		if err != nil {
+			logger.Error("Error: counting trips", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, results)
+	}
+}
+
+// TripsByMedallionPerDay query for number of trips made by a medallion on each pick up date in the inclusive range.
+func TripsByMedallionPerDay(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		medallion, err := validation.Medallion(mux.Vars(r)["medallion"])
+		if err != nil {
+			invalid.AddErr("medallion", err)
+		}
+		from, to, err := parseDateRange(r)
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else if to.Sub(from) >= validation.MaxDays*24*time.Hour {
+			invalid.Add("to", "max date range is 366 days")
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		byPassCache, err := parseByPassCache(r)
+		if err != nil {
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		results, err := tripSvc.TripsByMedallionPerDay(r.Context(), medallion, from, to, basis, byPassCache)
+		if err != nil {
+			logger.Error("Error: counting daily trips", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, results)
+	}
+}
+
+// Metrics query for trip count, distance, duration, passengers and first and last pick up per medallion.
+// The from and to dates are optional but must be supplied together, they run from midnight to midnight in the tz time zone.
+func Metrics(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		medallions, err := validation.Medallions(strings.Split(mux.Vars(r)["medallions"], ","), validation.MaxMedallions)
+		if err != nil {
+			invalid.AddErr("medallions", err)
+		}
+
+		from, to, err := validation.Dates(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		zone, err := parseZone(r)
+		if err != nil {
+			invalid.Add("tz", "invalid tz value")
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		byPassCache, err := parseByPassCache(r)
+		if err != nil {
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		if !from.IsZero() {
+			from, to = validation.InZone(from, zone), validation.InZone(to, zone)
+		}
+		results, err := tripSvc.Metrics(r.Context(), medallions, from, to, basis, byPassCache)
+		if err != nil {
+			logger.Error("Error: finding metrics", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, results)
+	}
+}
+
+// PickUpHistogram query for number of trips of medallions by hour of day and day of week of pick up.
+// The from and to dates are optional.
+func PickUpHistogram(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		medallions, err := validation.Medallions(strings.Split(mux.Vars(r)["medallions"], ","), validation.MaxMedallions)
+		if err != nil {
+			invalid.AddErr("medallions", err)
+		}
+
+		from, to, err := parseOptionalDateRange(r)
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		results, err := tripSvc.PickUpHistogram(r.Context(), medallions, from, to, basis)
+		if err != nil {
+			logger.Error("Error: counting trips", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, results)
+	}
+}
+
+// TripsByQuery query for number of trips per medallion for a large set of medallions.
+// Results are streamed as newline delimited JSON as soon as each batch is available.
+func TripsByQuery(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		var q Query
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQueryBytes)).Decode(&q); err != nil {
+			logger.Error("Error: query is not valid", zap.Error(err))
+			invalid.Add("body", "invalid query")
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		medallions, err := validation.Medallions(q.Medallions, validation.MaxBulkMedallions)
+		if err != nil {
+			invalid.AddErr("medallions", err)
+		}
+
+		from, to, err := validation.Dates(q.From, q.To)
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		basis, err := validation.Basis(q.Basis)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		flusher, _ := w.(http.Flusher)
+		streaming := false
+		err = tripSvc.StreamTripsByMedallions(r.Context(), medallions, from, to, basis, q.ByPassCache, func(results []output.Result) error {
+			if !streaming {
+				w.Header().Set("Content-Type", "application/x-ndjson; charset=UTF-8")
+				w.WriteHeader(http.StatusOK)
+				streaming = true
+			}
+			for _, res := range results {
+				if err := enc.Encode(res); err != nil {
+					return err
+				}
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error("Error: counting trips", zap.Error(err))
+			if !streaming {
+				responseFailure(w, r, err)
+				return
+			}
+			enc.Encode(failureOf(r, err))
+			return
+		}
+		if !streaming {
+			w.Header().Set("Content-Type", "application/x-ndjson; charset=UTF-8")
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+}
+
+// Trips query for the trip records of a medallion a page at a time.
+// The from, to, limit and cursor parameters are optional.
+func Trips(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		medallion, err := validation.Medallion(mux.Vars(r)["medallion"])
+		if err != nil {
+			invalid.AddErr("medallion", err)
+		}
+		from, to, err := parseOptionalDateRange(r)
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		limit, err := parseLimit(r, defaultLimit, maxLimit)
+		if err != nil {
+			invalid.Add("limit", "invalid limit")
+		}
+
+		cursor, err := parseCursor(r)
+		if err != nil {
+			invalid.Add("cursor", "invalid cursor")
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		page, err := tripSvc.Trips(r.Context(), medallion, from, to, basis, cursor, limit)
+		if err != nil {
+			logger.Error("Error: listing trips", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, page)
+	}
+}
+
+// TopMedallions query for the medallions with the most trips.
+// The from, to and limit parameters are optional.
+func TopMedallions(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		from, to, err := parseOptionalDateRange(r)
+		if err != nil {
+			invalid.AddErr("from", err)
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		limit, err := parseLimit(r, defaultTopLimit, maxTopLimit)
+		if err != nil {
+			invalid.Add("limit", "invalid limit")
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		byPassCache, err := parseByPassCache(r)
+		if err != nil {
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		results, err := tripSvc.TopMedallions(r.Context(), from, to, basis, limit, byPassCache)
+		if err != nil {
+			logger.Error("Error: ranking medallions", zap.Error(err))
+			responseFailure(w, r, err)
 			return
 		}
 		responseOK(w, enc, results)
 	}
 }
 
-// ClearCache flushes the cache entries.
-func ClearCache(logger *zap.Logger, cache Clearer) http.HandlerFunc {
+// TripsByDriver query for number of trips and medallions operated per driver.
+func TripsByDriver(logger *zap.Logger, tripSvc Servicer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		licenses, err := validation.Licenses(strings.Split(mux.Vars(r)["licenses"], ","), maxLicenses)
+		if err != nil {
+			invalid.AddErr("licenses", err)
+		}
+
+		byPassCache, err := parseByPassCache(r)
+		if err != nil {
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		results, err := tripSvc.TripsByDriver(r.Context(), licenses, byPassCache)
+		if err != nil {
+			logger.Error("Error: counting driver trips", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, results)
+	}
+}
+
+// TripsByDriverOnPickUpDate get number of trips and medallions operated by a driver on pick up date.
+// The pick up date runs from midnight to midnight in the tz time zone.
+func TripsByDriverOnPickUpDate(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		license, err := validation.License(mux.Vars(r)["license"])
+		if err != nil {
+			invalid.AddErr("license", err)
+		}
+		pickupDate, err := parsePickUpDate(r)
+		if err != nil {
+			invalid.Add("pickupdate", "invalid pick up date")
+		} else {
+			invalid.Check(bounds.Date("pickupdate", pickupDate))
+		}
+
+		zone, err := parseZone(r)
+		if err != nil {
+			invalid.Add("tz", "invalid tz value")
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		byPassCache, err := parseByPassCache(r)
+		if err != nil {
+			invalid.Add("bypasscache", "invalid bypasscache value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		result, err := tripSvc.TripsByDriverOnPickUpDate(r.Context(), license, validation.InZone(pickupDate, zone), basis, byPassCache)
+		if err != nil {
+			logger.Error("Error: counting driver trips", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, result)
+	}
+}
+
+// TripsInArea query for number of trips with pick up inside a bounding box or a radius around a point.
+// The date range is given either by date or by from and to, medallions are optional.
+func TripsInArea(logger *zap.Logger, tripSvc Servicer, bounds validation.Bounds) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		area, err := parseArea(r)
+		if err != nil {
+			invalid.Add("area", "invalid area: "+err.Error())
+		}
+
+		from, to, err := parseAreaDates(r)
+		if err != nil {
+			invalid.AddErr("date", err)
+		} else if to.Sub(from) >= validation.MaxDays*24*time.Hour {
+			invalid.Add("to", "max date range is 366 days")
+		} else {
+			invalid.Check(bounds.Date("from", from))
+			invalid.Check(bounds.Date("to", to))
+		}
+
+		var medallions []string
+		if val := r.URL.Query().Get("medallions"); len(val) > 0 {
+			if medallions, err = validation.Medallions(strings.Split(val, ","), validation.MaxMedallions); err != nil {
+				invalid.AddErr("medallions", err)
+			}
+		}
+
+		perMedallion := false
+		if val := r.URL.Query().Get("permedallion"); len(val) > 0 {
+			if perMedallion, err = strconv.ParseBool(val); err != nil {
+				invalid.Add("permedallion", "invalid permedallion value")
+			}
+		}
+
+		basis, err := parseBasis(r)
+		if err != nil {
+			invalid.Add("basis", "invalid basis value")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		result, err := tripSvc.TripsInArea(r.Context(), area, from, to, basis, medallions, perMedallion)
+		if err != nil {
+			logger.Error("Error: counting trips in area", zap.Error(err))
+			responseFailure(w, r, err)
+			return
+		}
+		responseOK(w, enc, result)
+	}
+}
+
+// Medallions lists the known medallions with their trips and first and last pick up dates.
+// The prefix, sort, order, offset and limit parameters are optional.
+func Medallions(logger *zap.Logger, lister Lister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		queryValues := r.URL.Query()
+
+		var invalid InvalidParams
+		order, err := registry.ParseSort(queryValues.Get("sort"))
+		if err != nil {
+			invalid.Add("sort", "invalid sort value")
+		}
+
+		desc, err := parseDesc(r)
+		if err != nil {
+			invalid.Add("order", "invalid order value")
+		}
+
+		offset, err := parseOffset(r)
+		if err != nil {
+			invalid.Add("offset", "invalid offset")
+		}
+
+		limit, err := parseLimit(r, defaultMedallionLimit, maxMedallionLimit)
+		if err != nil {
+			invalid.Add("limit", "invalid limit")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		opts := registry.Options{Prefix: queryValues.Get("prefix"), Sort: order, Desc: desc, Offset: offset, Limit: limit}
+		responseOK(w, enc, lister.Medallions(r.Context(), opts))
+	}
+}
+
+// ClearCache flushes the cache entries, or invalidates the entries selected by medallion, from, to and prefix.
+// Dates select the entries of a medallion, every entry of the medallion when both are missing.
+func ClearCache(logger *zap.Logger, clearer Clearer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		queryValues := r.URL.Query()
+		if len(queryValues.Get("medallion")) == 0 && len(queryValues.Get("from")) == 0 && len(queryValues.Get("to")) == 0 && len(queryValues.Get("prefix")) == 0 {
+			evicted := clearer.Clear(r.Context())
+			logger.Info("flushed cache entries", zap.Int("evicted", evicted))
+			responseOK(w, enc, ClearResult{Message: "cache cleared", Evicted: evicted})
+			return
+		}
+
+		var invalid InvalidParams
+		sel, err := parseSelector(r)
+		if err != nil {
+			invalid.AddErr("medallion", err)
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		evicted := clearer.Invalidate(r.Context(), sel)
+		logger.Info("invalidated cache entries", zap.Any("selector", sel), zap.Int("evicted", evicted))
+		responseOK(w, enc, ClearResult{Message: "cache entries invalidated", Evicted: evicted})
+	}
+}
+
+// ClearResult represents the outcome of clearing the cache.
+type ClearResult struct {
+	Message string `json:"message"`
+	Evicted int    `json:"evicted"`
+}
+
+// parseSelector reads the cache entries selected by medallion, from, to and prefix.
+func parseSelector(r *http.Request) (cache.Selector, error) {
+	queryValues := r.URL.Query()
+	sel := cache.Selector{Prefix: queryValues.Get("prefix")}
+	from, to, err := parseOptionalDateRange(r)
+	if err != nil {
+		return cache.Selector{}, err
+	}
+	if val := queryValues.Get("medallion"); len(val) > 0 {
+		if sel.Medallion, err = validation.Medallion(val); err != nil {
+			return cache.Selector{}, err
+		}
+	} else if !from.IsZero() || !to.IsZero() {
+		return cache.Selector{}, failure.Invalid("medallion", "dates select the entries of a medallion")
+	}
+	sel.From, sel.To = from, to
+	return sel, nil
+}
+
+// CacheStats reports the entries, hits, misses, hit ratio, evictions and approximate bytes of the cache.
+func CacheStats(logger *zap.Logger, inspector Inspector) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cache.Clear(r.Context())
-		logger.Info("flushed cache entries")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`Cache Cleared`))
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		responseOK(w, enc, inspector.Stats(r.Context()))
+	}
+}
+
+// CacheEntries lists the cache entries with keys starting with prefix, with their value, age and remaining time to live.
+func CacheEntries(logger *zap.Logger, inspector Inspector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var invalid InvalidParams
+		limit, err := parseLimit(r, defaultEntries, maxEntries)
+		if err != nil {
+			invalid.Add("limit", "invalid limit")
+		}
+
+		if len(invalid) > 0 {
+			logger.Error("Error: request is not valid", zap.Any("invalid", invalid))
+			responseBadRequest(w, r, invalid)
+			return
+		}
+
+		responseOK(w, enc, inspector.Entries(r.Context(), r.URL.Query().Get("prefix"), limit))
 	}
 }
 
@@ -114,43 +884,205 @@ func parsePickUpDate(r *http.Request) (time.Time, error) {
 	return pickupDate, nil
 }
 
-func parseByPassCache(r *http.Request) (bool, error) {
+// parseZone defaults to New York, the time zone the trips were recorded in.
+func parseZone(r *http.Request) (*time.Location, error) {
+	return validation.Zone(r.URL.Query().Get("tz"))
+}
+
+func parseDateRange(r *http.Request) (time.Time, time.Time, error) {
 	queryValues := r.URL.Query()
-	val := queryValues.Get("bypasscache")
+	from, err := validation.Date(queryValues.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, failure.Invalid("from", "invalid from date")
+	}
+	to, err := validation.Date(queryValues.Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, failure.Invalid("to", "invalid to date")
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, failure.Invalid("from", "from date is after to date")
+	}
+	return from, to, nil
+}
+
+// parseOptionalDateRange returns zero time for a missing from or to date.
+func parseOptionalDateRange(r *http.Request) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	queryValues := r.URL.Query()
+	if val := queryValues.Get("from"); len(val) > 0 {
+		if from, err = validation.Date(val); err != nil {
+			return time.Time{}, time.Time{}, failure.Invalid("from", "invalid from date")
+		}
+	}
+	if val := queryValues.Get("to"); len(val) > 0 {
+		if to, err = validation.Date(val); err != nil {
+			return time.Time{}, time.Time{}, failure.Invalid("to", "invalid to date")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return time.Time{}, time.Time{}, failure.Invalid("from", "from date is after to date")
+	}
+	return from, to, nil
+}
+
+// parseArea reads a bounding box from minlat, minlng, maxlat and maxlng
+// or a radius in meters around lat and lng.
+func parseArea(r *http.Request) (geo.Area, error) {
+	queryValues := r.URL.Query()
+	if len(queryValues.Get("radius")) > 0 {
+		vals, err := parseFloats(queryValues, "lat", "lng", "radius")
+		if err != nil {
+			return geo.Area{}, err
+		}
+		center := geo.Point{Lat: vals[0], Lng: vals[1]}
+		if err := center.Validate(); err != nil {
+			return geo.Area{}, err
+		}
+		if vals[2] <= 0 || vals[2] > maxRadius {
+			return geo.Area{}, fmt.Errorf("radius must be between 0 and %d meters", maxRadius)
+		}
+		return geo.NewRadiusArea(center, vals[2]), nil
+	}
+	vals, err := parseFloats(queryValues, "minlat", "minlng", "maxlat", "maxlng")
+	if err != nil {
+		return geo.Area{}, err
+	}
+	box := geo.Box{Min: geo.Point{Lat: vals[0], Lng: vals[1]}, Max: geo.Point{Lat: vals[2], Lng: vals[3]}}
+	if err := box.Validate(); err != nil {
+		return geo.Area{}, err
+	}
+	return geo.NewBoxArea(box), nil
+}
+
+func parseFloats(queryValues url.Values, names ...string) ([]float64, error) {
+	vals := make([]float64, len(names))
+	for i, name := range names {
+		val := queryValues.Get(name)
+		if len(val) == 0 {
+			return nil, fmt.Errorf("%s missing", name)
+		}
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%s is not a number", name)
+		}
+		vals[i] = f
+	}
+	return vals, nil
+}
+
+// parseAreaDates reads a single date or a from and to date range.
+func parseAreaDates(r *http.Request) (time.Time, time.Time, error) {
+	if val := r.URL.Query().Get("date"); len(val) > 0 {
+		date, err := validation.Date(val)
+		if err != nil {
+			return time.Time{}, time.Time{}, failure.Invalid("date", "invalid date")
+		}
+		return date, date, nil
+	}
+	return parseDateRange(r)
+}
+
+func parseLimit(r *http.Request, def, max int) (int, error) {
+	val := r.URL.Query().Get("limit")
 	if len(val) == 0 {
-		return false, nil
+		return def, nil
 	}
-	flag, err := strconv.ParseBool(val)
+	limit, err := strconv.Atoi(val)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
-	return flag, nil
+	if limit < 1 || limit > max {
+		return 0, fmt.Errorf("limit must be between 1 and %d", max)
+	}
+	return limit, nil
 }
 
-func responseOK(w http.ResponseWriter, encoder *json.Encoder, response interface{}) {
-	w.WriteHeader(http.StatusOK)
-	encoder.Encode(response)
+func parseOffset(r *http.Request) (int, error) {
+	val := r.URL.Query().Get("offset")
+	if len(val) == 0 {
+		return 0, nil
+	}
+	offset, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, errors.New("offset must not be negative")
+	}
+	return offset, nil
 }
 
-func responseBadRequest(w http.ResponseWriter, encoder *json.Encoder, response string) {
-	w.WriteHeader(http.StatusBadRequest)
-	encoder.Encode(NewErrorMsg(response))
+// parseDesc defaults to ascending order.
+func parseDesc(r *http.Request) (bool, error) {
+	switch val := r.URL.Query().Get("order"); val {
+	case "", "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, fmt.Errorf("order must be asc or desc, got %s", val)
+	}
 }
 
-func serverError(w http.ResponseWriter, encoder *json.Encoder, response string) {
-	code := http.StatusInternalServerError
-	w.WriteHeader(code)
-	encoder.Encode(NewErrorMsg(response))
+// parseCursor returns nil for a missing cursor.
+func parseCursor(r *http.Request) (*output.Cursor, error) {
+	val := r.URL.Query().Get("cursor")
+	if len(val) == 0 {
+		return nil, nil
+	}
+	cursor, err := output.DecodeCursor(val)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
 }
 
-// ErrorMsg represent error msg.
-type ErrorMsg struct {
-	Message string `json:"message"`
+func parseBasis(r *http.Request) (output.Basis, error) {
+	return validation.Basis(r.URL.Query().Get("basis"))
+}
+
+// parseFields returns nil when no fields are requested.
+func parseFields(r *http.Request) ([]output.Field, error) {
+	val := r.URL.Query().Get("fields")
+	if len(val) == 0 {
+		return nil, nil
+	}
+	var fields []output.Field
+	for _, f := range strings.Split(val, ",") {
+		switch field := output.Field(f); field {
+		case output.Distance, output.Duration, output.Passengers, output.PickUps:
+			fields = append(fields, field)
+		default:
+			return nil, fmt.Errorf("unknown field %s", f)
+		}
+	}
+	return fields, nil
 }
 
-// NewErrorMsg new error message.
-func NewErrorMsg(message string) *ErrorMsg {
-	return &ErrorMsg{
-		Message: message,
+// extend keeps the trips and the metrics of fields for each medallion.
+func extend(metrics []output.Metrics, fields []output.Field) []output.ExtendedResult {
+	results := make([]output.ExtendedResult, 0, len(metrics))
+	for _, m := range metrics {
+		results = append(results, output.NewExtendedResult(m, fields))
+	}
+	return results
+}
+
+func parseByPassCache(r *http.Request) (bool, error) {
+	queryValues := r.URL.Query()
+	val := queryValues.Get("bypasscache")
+	if len(val) == 0 {
+		return false, nil
+	}
+	flag, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, err
 	}
+	return flag, nil
+}
+
+func responseOK(w http.ResponseWriter, encoder *json.Encoder, response interface{}) {
+	w.WriteHeader(http.StatusOK)
+	encoder.Encode(response)
 }
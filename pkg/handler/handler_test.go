@@ -2,9 +2,13 @@ package handler_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,12 +17,26 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
 	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/registry"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
 	"github.com/nikhil-github/api-cab-data/pkg/wiring"
 )
 
+// requestID is sent by the tests and echoed in problems.
+const requestID = "test-request"
+
 func TestHandler_TripsByMedAndPickUpDate(t *testing.T) {
-	res := output.Result{Medallion: "YYYY", Trips: 10}
+	res := output.Result{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := time.Date(2013, 12, 31, 0, 0, 0, 0, newYork)
 	type args struct {
 		URL  string
 		Path string
@@ -44,39 +62,49 @@ func TestHandler_TripsByMedAndPickUpDate(t *testing.T) {
 		},
 		{
 			Name: "Service failed to query count",
-			Args: args{Path: "/trips/v1/medallion/TTTTTTTT/pickupdate/2013-12-31"},
+			Args: args{Path: "/trips/v1/medallion/0BD7C8F5BA12B88E0B67BED28BEA73D8/pickupdate/2013-12-31"},
 			Fields: fields{MockExpectations: func(m *mockTripSvc) {
-				pd, err := time.Parse("2006-01-02", "2013-12-31")
-				if err != nil {
-					t.Fail()
-				}
-				m.OnTripsByPickUpDate("TTTTTTTT", pd, false).Return(output.Result{}, errors.New("error"))
+				m.OnTripsByPickUpDate([]string{"0BD7C8F5BA12B88E0B67BED28BEA73D8"}, pd, output.PickUp, false).Return([]output.Result{}, errors.New("error"))
 			}},
 			Want: want{Status: http.StatusInternalServerError},
 		},
 		{
 			Name: "Success with one medallion",
-			Args: args{Path: "/trips/v1/medallion/YYYY/pickupdate/2013-12-31"},
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-31"},
 			Fields: fields{MockExpectations: func(m *mockTripSvc) {
-				pd, err := time.Parse("2006-01-02", "2013-12-31")
-				if err != nil {
-					t.Fail()
-				}
-				m.OnTripsByPickUpDate("YYYY", pd, false).Return(res, nil)
+				m.OnTripsByPickUpDate([]string{"D7D598CD99978BD012A87A76A7C891B7"}, pd, output.PickUp, false).Return([]output.Result{res}, nil)
 			}},
-			Want: want{Status: http.StatusOK, Body: `{"medallion":"YYYY","trips":10}`},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}`},
 		},
 		{
 			Name: "Success with by pass cache flag",
-			Args: args{Path: "/trips/v1/medallion/YYYY/pickupdate/2013-12-31?bypasscache=true"},
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-31?bypasscache=true"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByPickUpDate([]string{"D7D598CD99978BD012A87A76A7C891B7"}, pd, output.PickUp, true).Return([]output.Result{res}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}`},
+		},
+		{
+			Name:   "Failure - Invalid tz",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-31?tz=Mars/Olympus"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid tz","instance":"/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-31","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"tz","reason":"invalid tz value"}]}`},
+		},
+		{
+			Name: "Success with tz",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-31?tz=UTC"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByPickUpDate([]string{"D7D598CD99978BD012A87A76A7C891B7"}, time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC), output.PickUp, false).Return([]output.Result{res}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}`},
+		},
+		{
+			Name: "Success with multiple medallions",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735/pickupdate/2013-12-31"},
 			Fields: fields{MockExpectations: func(m *mockTripSvc) {
-				pd, err := time.Parse("2006-01-02", "2013-12-31")
-				if err != nil {
-					t.Fail()
-				}
-				m.OnTripsByPickUpDate("YYYY", pd, true).Return(res, nil)
+				m.OnTripsByPickUpDate([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, pd, output.PickUp, false).Return([]output.Result{res, {Medallion: "5455D5FF2BD94D10B304A15D4B7F2735", Trips: 0}}, nil)
 			}},
-			Want: want{Status: http.StatusOK, Body: `{"medallion":"YYYY","trips":10}`},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10},{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":0}]`},
 		},
 	}
 	for _, tt := range testTable {
@@ -90,7 +118,7 @@ func TestHandler_TripsByMedAndPickUpDate(t *testing.T) {
 			mx := wiring.NewRouter(params)
 			ts := httptest.NewServer(mx)
 			defer ts.Close()
-			res, err := http.Get(ts.URL + tt.Args.Path)
+			res, err := get(ts.URL + tt.Args.Path)
 			assert.NoError(t, err, "Error executing request")
 			defer res.Body.Close()
 			m.AssertExpectations(t)
@@ -105,8 +133,8 @@ func TestHandler_TripsByMedAndPickUpDate(t *testing.T) {
 }
 
 func TestHandler_TripsByMedallions(t *testing.T) {
-	res := output.Result{Medallion: "YYYY", Trips: 10}
-	res2 := output.Result{Medallion: "ZZZZ", Trips: 3}
+	res := output.Result{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}
+	res2 := output.Result{Medallion: "5455D5FF2BD94D10B304A15D4B7F2735", Trips: 3}
 	type args struct {
 		URL  string
 		Path string
@@ -126,28 +154,43 @@ func TestHandler_TripsByMedallions(t *testing.T) {
 	}{
 		{
 			Name: "Service failed to query count",
-			Args: args{Path: "/trips/v1/medallions/TTTTTTTT"},
+			Args: args{Path: "/trips/v1/medallions/0BD7C8F5BA12B88E0B67BED28BEA73D8"},
 			Fields: fields{MockExpectations: func(m *mockTripSvc) {
-				m.OnTripsTripsByMedallion([]string{"TTTTTTTT"}, false).Return([]output.Result{}, errors.New("error"))
+				m.OnTripsTripsByMedallion([]string{"0BD7C8F5BA12B88E0B67BED28BEA73D8"}, false).Return([]output.Result{}, errors.New("error"))
 			}},
 			Want: want{Status: http.StatusInternalServerError},
 		},
 		{
 			Name: "Success with one medallion",
-			Args: args{Path: "/trips/v1/medallions/YYYY"},
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7"},
 			Fields: fields{MockExpectations: func(m *mockTripSvc) {
 
-				m.OnTripsTripsByMedallion([]string{"YYYY"}, false).Return([]output.Result{res}, nil)
+				m.OnTripsTripsByMedallion([]string{"D7D598CD99978BD012A87A76A7C891B7"}, false).Return([]output.Result{res}, nil)
 			}},
-			Want: want{Status: http.StatusOK, Body: `[{"medallion":"YYYY","trips":10}]`},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}]`},
 		},
 		{
 			Name: "Success with multiple medallion",
-			Args: args{Path: "/trips/v1/medallions/YYYY,ZZZZ"},
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsTripsByMedallion([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, false).Return([]output.Result{res, res2}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10},{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":3}]`},
+		},
+		{
+			Name:   "Failure - Unknown field",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7?fields=distance,speed"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid fields","instance":"/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"fields","reason":"invalid fields"}]}`},
+		},
+		{
+			Name: "Success with opted in fields",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7?fields=distance,passengers"},
 			Fields: fields{MockExpectations: func(m *mockTripSvc) {
-				m.OnTripsTripsByMedallion([]string{"YYYY", "ZZZZ"}, false).Return([]output.Result{res, res2}, nil)
+				m.OnMetrics([]string{"D7D598CD99978BD012A87A76A7C891B7"}, time.Time{}, time.Time{}, output.PickUp, false).
+					Return([]output.Metrics{{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10, TotalDistance: 25, AverageDistance: 2.5, TotalDuration: 6000, AverageDuration: 600, Passengers: 14}}, nil)
 			}},
-			Want: want{Status: http.StatusOK, Body: `[{"medallion":"YYYY","trips":10},{"medallion":"ZZZZ","trips":3}]`},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10,"totalDistance":25,"averageDistance":2.5,"passengers":14}]`},
 		},
 	}
 	for _, tt := range testTable {
@@ -161,7 +204,7 @@ func TestHandler_TripsByMedallions(t *testing.T) {
 			mx := wiring.NewRouter(params)
 			ts := httptest.NewServer(mx)
 			defer ts.Close()
-			res, err := http.Get(ts.URL + tt.Args.Path)
+			res, err := get(ts.URL + tt.Args.Path)
 			assert.NoError(t, err, "Error executing request")
 			defer res.Body.Close()
 			m.AssertExpectations(t)
@@ -175,24 +218,1603 @@ func TestHandler_TripsByMedallions(t *testing.T) {
 	}
 }
 
-type mockTripSvc struct {
-	mock.Mock
-}
-
-func (m *mockTripSvc) TripsByMedallionsOnPickUpDate(ctx context.Context, medallion string, pickUpDate time.Time, byPassCache bool) (output.Result, error) {
-	args := m.Called(ctx, medallion, pickUpDate, byPassCache)
-	return args.Get(0).(output.Result), args.Error(1)
+func TestHandler_TripsByMedallionsInDateRange(t *testing.T) {
+	res := output.Result{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}
+	res2 := output.Result{Medallion: "5455D5FF2BD94D10B304A15D4B7F2735", Trips: 3}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, newYork)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, newYork)
+	type args struct {
+		URL  string
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Missing from date",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Invalid to date",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-01&to=2013-12-3p"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - From after to",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-31&to=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query count",
+			Args: args{Path: "/trips/v1/medallions/0BD7C8F5BA12B88E0B67BED28BEA73D8/pickups?from=2013-12-01&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByDateRange([]string{"0BD7C8F5BA12B88E0B67BED28BEA73D8"}, from, to, output.PickUp, false).Return([]output.Result{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success with same from and to date",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-31&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByDateRange([]string{"D7D598CD99978BD012A87A76A7C891B7"}, to, to, output.PickUp, false).Return([]output.Result{res}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}]`},
+		},
+		{
+			Name: "Success with dates in UTC",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-01&to=2013-12-31&tz=UTC"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByDateRange([]string{"D7D598CD99978BD012A87A76A7C891B7"}, time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC), output.PickUp, false).Return([]output.Result{res}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}]`},
+		},
+		{
+			Name: "Success with multiple medallion and by pass cache flag",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735/pickups?from=2013-12-01&to=2013-12-31&bypasscache=true"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByDateRange([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, from, to, output.PickUp, true).Return([]output.Result{res, res2}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10},{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":3}]`},
+		},
+		{
+			Name:   "Failure - Invalid basis",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-01&to=2013-12-31&basis=midway"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid basis","instance":"/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"basis","reason":"invalid basis value"}]}`},
+		},
+		{
+			Name: "Success with drop off basis",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-01&to=2013-12-31&basis=dropoff"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByDateRange([]string{"D7D598CD99978BD012A87A76A7C891B7"}, from, to, output.DropOff, false).Return([]output.Result{res}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}]`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
 }
 
-func (m *mockTripSvc) OnTripsByPickUpDate(medallion string, pickUpDate time.Time, byPassCache bool) *mock.Call {
-	return m.On("TripsByMedallionsOnPickUpDate", mock.AnythingOfType("*context.valueCtx"), medallion, pickUpDate, byPassCache)
+func TestHandler_TripsByMedallionPerDay(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 2, 0, 0, 0, 0, time.UTC)
+	res := []output.DailyResult{{Date: "2013-12-01", Trips: 4}, {Date: "2013-12-02", Trips: 0}}
+	type args struct {
+		URL  string
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - From after to",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickups/daily?from=2013-12-31&to=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Date range too long",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickups/daily?from=2012-01-01&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query count",
+			Args: args{Path: "/trips/v1/medallion/0BD7C8F5BA12B88E0B67BED28BEA73D8/pickups/daily?from=2013-12-01&to=2013-12-02"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsPerDay("0BD7C8F5BA12B88E0B67BED28BEA73D8", from, to, output.PickUp, false).Return([]output.DailyResult{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickups/daily?from=2013-12-01&to=2013-12-02&bypasscache=true"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsPerDay("D7D598CD99978BD012A87A76A7C891B7", from, to, output.PickUp, true).Return(res, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"date":"2013-12-01","trips":4},{"date":"2013-12-02","trips":0}]`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
 }
 
-func (m *mockTripSvc) TripsByMedallion(ctx context.Context, medallions []string, byPassCache bool) ([]output.Result, error) {
-	args := m.Called(ctx, medallions, byPassCache)
-	return args.Get(0).([]output.Result), args.Error(1)
+func TestHandler_PickUpHistogram(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	hist := output.Histogram{Medallions: []string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}}
+	hist.HourOfDay[8] = 2
+	hist.DayOfWeek[1] = 2
+	type args struct {
+		URL  string
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid from date",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/histogram?from=2013-1p-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - From after to",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/histogram?from=2013-12-31&to=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query histogram",
+			Args: args{Path: "/trips/v1/medallions/0BD7C8F5BA12B88E0B67BED28BEA73D8/histogram"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnHistogram([]string{"0BD7C8F5BA12B88E0B67BED28BEA73D8"}, time.Time{}, time.Time{}, output.PickUp).Return(output.Histogram{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success with open ended date range",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735/histogram?from=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnHistogram([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, from, time.Time{}, output.PickUp).Return(hist, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallions":["D7D598CD99978BD012A87A76A7C891B7","5455D5FF2BD94D10B304A15D4B7F2735"],"hourOfDay":[0,0,0,0,0,0,0,0,2,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0],"dayOfWeek":[0,2,0,0,0,0,0]}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
 }
 
-func (m *mockTripSvc) OnTripsTripsByMedallion(medallions []string, byPassCache bool) *mock.Call {
-	return m.On("TripsByMedallion", mock.AnythingOfType("*context.valueCtx"), medallions, byPassCache)
+func TestHandler_TripsByQuery(t *testing.T) {
+	res := output.Result{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}
+	res2 := output.Result{Medallion: "5455D5FF2BD94D10B304A15D4B7F2735", Trips: 3}
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Body string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid body",
+			Args:   args{Body: `{"medallions":`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Missing medallions",
+			Args:   args{Body: `{"medallions":[]}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Only from date",
+			Args:   args{Body: `{"medallions":["D7D598CD99978BD012A87A76A7C891B7"],"from":"2013-12-01"}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed before streaming",
+			Args: args{Body: `{"medallions":["0BD7C8F5BA12B88E0B67BED28BEA73D8"]}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnStreamTrips([]string{"0BD7C8F5BA12B88E0B67BED28BEA73D8"}, time.Time{}, time.Time{}, output.PickUp, false).Return(errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError, Body: `{"type":"/problems/internal_error","title":"Internal Server Error","status":500,"detail":"service failure","instance":"/trips/v1/queries","requestId":"test-request","code":"internal_error"}` + "\n"},
+		},
+		{
+			Name: "Success streams each batch",
+			Args: args{Body: `{"medallions":["D7D598CD99978BD012A87A76A7C891B7","5455D5FF2BD94D10B304A15D4B7F2735"],"from":"2013-12-01","to":"2013-12-31","bypasscache":true}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnStreamTrips([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, from, to, output.PickUp, true, []output.Result{res}, []output.Result{res2}).Return(nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}` + "\n" + `{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":3}` + "\n"},
+		},
+		{
+			Name:   "Failure - Invalid basis",
+			Args:   args{Body: `{"medallions":["D7D598CD99978BD012A87A76A7C891B7"],"basis":"midway"}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Success with drop off basis",
+			Args: args{Body: `{"medallions":["D7D598CD99978BD012A87A76A7C891B7"],"from":"2013-12-01","to":"2013-12-31","basis":"dropoff"}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnStreamTrips([]string{"D7D598CD99978BD012A87A76A7C891B7"}, from, to, output.DropOff, false, []output.Result{res}).Return(nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}` + "\n"},
+		},
+		{
+			Name: "Service failed while streaming",
+			Args: args{Body: `{"medallions":["D7D598CD99978BD012A87A76A7C891B7","5455D5FF2BD94D10B304A15D4B7F2735"]}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnStreamTrips([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, time.Time{}, time.Time{}, output.PickUp, false, []output.Result{res}).Return(errors.New("error"))
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}` + "\n" + `{"type":"/problems/internal_error","title":"Internal Server Error","status":500,"detail":"service failure","instance":"/trips/v1/queries","requestId":"test-request","code":"internal_error"}` + "\n"},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := send(http.MethodPost, ts.URL+"/trips/v1/queries", strings.NewReader(tt.Args.Body))
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.Equal(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
+}
+
+func TestHandler_GraphQL(t *testing.T) {
+	type args struct {
+		Body string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid body",
+			Args:   args{Body: `{"query":`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid body","instance":"/graphql","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"body","reason":"invalid query"}]}`},
+		},
+		{
+			Name:   "Failure - Missing query",
+			Args:   args{Body: `{"query":" "}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid query","instance":"/graphql","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"query","reason":"missing query"}]}`},
+		},
+		{
+			Name: "Success with variables",
+			Args: args{Body: `{"query":"query Trips($ids: [String!]!) { medallions(ids: $ids) { id trips } }","operationName":"Trips","variables":{"ids":["d7d598cd99978bd012a87a76a7c891b7"]}}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallion", mock.Anything, []string{"D7D598CD99978BD012A87A76A7C891B7"}, false).
+					Return([]output.Result{{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10, Status: output.Known}}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"data":{"medallions":[{"id":"D7D598CD99978BD012A87A76A7C891B7","trips":10}]}}`},
+		},
+		{
+			Name: "Service failure is a query error",
+			Args: args{Body: `{"query":"{ medallion(id: \"D7D598CD99978BD012A87A76A7C891B7\") { trips } }"}`},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallion", mock.Anything, []string{"D7D598CD99978BD012A87A76A7C891B7"}, false).
+					Return([]output.Result{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"errors":[{"message":"service failure","path":["medallion","trips"],"extensions":{"code":"internal_error"}}],"data":null}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = zap.NewNop()
+			ts := httptest.NewServer(wiring.NewRouter(params))
+			defer ts.Close()
+			res, err := send(http.MethodPost, ts.URL+"/graphql", strings.NewReader(tt.Args.Body))
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			assert.JSONEq(t, tt.Want.Body, string(body), "response")
+		})
+	}
+}
+
+func TestHandler_Trips(t *testing.T) {
+	pickUp := time.Date(2013, 12, 1, 10, 0, 0, 0, time.UTC)
+	dropOff := time.Date(2013, 12, 1, 10, 12, 0, 0, time.UTC)
+	cursor := output.Cursor{PickUpDateTime: pickUp, DropOffDateTime: dropOff, Skip: 1}
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	page := output.TripPage{
+		Trips:      []output.Trip{{Medallion: "D7D598CD99978BD012A87A76A7C891B7", PickUpDateTime: pickUp, DropOffDateTime: dropOff, PassengerCount: 1, TripTimeInSecs: 720, TripDistance: 2.5}},
+		NextCursor: cursor.Encode(),
+	}
+	type args struct {
+		URL  string
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid limit",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/trips?limit=1001"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Invalid cursor",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/trips?cursor=notacursor"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Cursor positioned after no trip",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/trips?cursor=" + output.Cursor{PickUpDateTime: pickUp, DropOffDateTime: dropOff}.Encode()},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to list trips",
+			Args: args{Path: "/trips/v1/medallion/0BD7C8F5BA12B88E0B67BED28BEA73D8/trips"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTrips("0BD7C8F5BA12B88E0B67BED28BEA73D8", time.Time{}, time.Time{}, output.PickUp, (*output.Cursor)(nil), 100).Return(output.TripPage{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success with cursor",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/trips?from=2013-12-01&to=2013-12-31&limit=1&cursor=" + cursor.Encode()},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTrips("D7D598CD99978BD012A87A76A7C891B7", from, to, output.PickUp, &cursor, 1).Return(page, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"trips":[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","pickupDatetime":"2013-12-01T10:00:00Z","dropoffDatetime":"2013-12-01T10:12:00Z","passengerCount":1,"tripTimeInSecs":720,"tripDistance":2.5,"pickupLongitude":0,"pickupLatitude":0,"dropoffLongitude":0,"dropoffLatitude":0}],"nextCursor":"` + cursor.Encode() + `"}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
+}
+
+func TestHandler_TopMedallions(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	ranking := []output.RankedResult{{Rank: 1, Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}, {Rank: 2, Medallion: "5455D5FF2BD94D10B304A15D4B7F2735", Trips: 3}}
+	type args struct {
+		URL  string
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid limit",
+			Args:   args{Path: "/trips/v1/leaderboard?limit=0"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - From after to",
+			Args:   args{Path: "/trips/v1/leaderboard?from=2013-12-31&to=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to rank medallions",
+			Args: args{Path: "/trips/v1/leaderboard"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTop(time.Time{}, time.Time{}, output.PickUp, 10, false).Return([]output.RankedResult{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success",
+			Args: args{Path: "/trips/v1/leaderboard?from=2013-12-01&to=2013-12-31&limit=2&bypasscache=true"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTop(from, to, output.PickUp, 2, true).Return(ranking, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"rank":1,"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10},{"rank":2,"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":3}]`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
+}
+
+func TestHandler_Failures(t *testing.T) {
+	type args struct {
+		Err error
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{
+			Name: "Not found",
+			Args: args{Err: errors.Wrap(failure.ErrNotFound, "medallion D7D598CD99978BD012A87A76A7C891B7")},
+			Want: want{Status: http.StatusNotFound, Body: `{"type":"/problems/not_found","title":"Not Found","status":404,"detail":"not found","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"not_found"}`},
+		},
+		{
+			Name: "Invalid input",
+			Args: args{Err: failure.Invalid("limit", "too large")},
+			Want: want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid input","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"invalid_input"}`},
+		},
+		{
+			Name: "Database unavailable",
+			Args: args{Err: errors.Wrap(failure.Mark(errors.New("dial tcp: connection refused"), failure.ErrUnavailable), "failed to query")},
+			Want: want{Status: http.StatusServiceUnavailable, Body: `{"type":"/problems/db_unavailable","title":"Service Unavailable","status":503,"detail":"database unavailable","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"db_unavailable"}`},
+		},
+		{
+			Name: "Query timeout",
+			Args: args{Err: errors.Wrap(failure.Mark(context.DeadlineExceeded, failure.ErrTimeout), "failed to query")},
+			Want: want{Status: http.StatusGatewayTimeout, Body: `{"type":"/problems/query_timeout","title":"Gateway Timeout","status":504,"detail":"query timeout","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"query_timeout"}`},
+		},
+		{
+			Name: "Request canceled",
+			Args: args{Err: errors.Wrap(failure.Mark(context.Canceled, failure.ErrCanceled), "failed to query")},
+			Want: want{Status: 499, Body: `{"type":"/problems/request_canceled","title":"Client Closed Request","status":499,"detail":"request canceled","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"request_canceled"}`},
+		},
+		{
+			Name: "Unclassified",
+			Args: args{Err: errors.New("error")},
+			Want: want{Status: http.StatusInternalServerError, Body: `{"type":"/problems/internal_error","title":"Internal Server Error","status":500,"detail":"service failure","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"internal_error"}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockTripSvc
+			m.OnTop(time.Time{}, time.Time{}, output.PickUp, 10, false).Return([]output.RankedResult{}, tt.Args.Err)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = zap.NewNop()
+			ts := httptest.NewServer(wiring.NewRouter(params))
+			defer ts.Close()
+			res, err := get(ts.URL + "/trips/v1/leaderboard")
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			assert.JSONEq(t, tt.Want.Body, string(body), "response")
+		})
+	}
+}
+
+func TestHandler_TripsByDriver(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := output.DriverResult{HackLicense: "2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D", Trips: 10, Medallions: []string{"D7D598CD99978BD012A87A76A7C891B7"}}
+	res2 := output.DriverResult{HackLicense: "6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A", Trips: 3, Medallions: []string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}}
+	type args struct {
+		URL  string
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid bypasscache",
+			Args:   args{Path: "/trips/v1/drivers/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D?bypasscache=maybe"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query count",
+			Args: args{Path: "/trips/v1/drivers/9A8B7C6D5E4F3A2B1C0D9E8F7A6B5C4D"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsDriver([]string{"9A8B7C6D5E4F3A2B1C0D9E8F7A6B5C4D"}, false).Return([]output.DriverResult{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name:   "Failure - Invalid license",
+			Args:   args{Path: "/trips/v1/drivers/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D,YYYY"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid licenses","instance":"/trips/v1/drivers/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D,YYYY","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"licenses","reason":"not 32 character hex licenses: YYYY"}]}`},
+		},
+		{
+			Name: "Success with duplicate and lower case licenses",
+			Args: args{Path: "/trips/v1/drivers/2c6c0b3f6f5b7a1a5f4e3a6d8e2b1c0d,6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A,,2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsDriver([]string{"2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D", "6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A"}, false).Return([]output.DriverResult{res, res2}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"hackLicense":"2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D","trips":10,"medallions":["D7D598CD99978BD012A87A76A7C891B7"]},{"hackLicense":"6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A","trips":3,"medallions":["D7D598CD99978BD012A87A76A7C891B7","5455D5FF2BD94D10B304A15D4B7F2735"]}]`},
+		},
+		{
+			Name: "Success with multiple drivers",
+			Args: args{Path: "/trips/v1/drivers/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D,6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsDriver([]string{"2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D", "6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A"}, false).Return([]output.DriverResult{res, res2}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"hackLicense":"2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D","trips":10,"medallions":["D7D598CD99978BD012A87A76A7C891B7"]},{"hackLicense":"6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A","trips":3,"medallions":["D7D598CD99978BD012A87A76A7C891B7","5455D5FF2BD94D10B304A15D4B7F2735"]}]`},
+		},
+		{
+			Name:   "Failure - Invalid pickup date",
+			Args:   args{Path: "/trips/v1/driver/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D/pickupdate/201p-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Invalid license on pickup date",
+			Args:   args{Path: "/trips/v1/driver/YYYY/pickupdate/2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query count on pickup date",
+			Args: args{Path: "/trips/v1/driver/9A8B7C6D5E4F3A2B1C0D9E8F7A6B5C4D/pickupdate/2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsDriverPdate("9A8B7C6D5E4F3A2B1C0D9E8F7A6B5C4D", time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp, false).Return(output.DriverResult{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name:   "Failure - Invalid tz on pickup date",
+			Args:   args{Path: "/trips/v1/driver/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D/pickupdate/2013-12-31?tz=Mars/Base"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Success on pickup date in UTC",
+			Args: args{Path: "/trips/v1/driver/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D/pickupdate/2013-12-31?tz=UTC"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsDriverPdate("2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D", time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC), output.PickUp, false).Return(res, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"hackLicense":"2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D","trips":10,"medallions":["D7D598CD99978BD012A87A76A7C891B7"]}`},
+		},
+		{
+			Name: "Success on pickup date",
+			Args: args{Path: "/trips/v1/driver/2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D/pickupdate/2013-12-31?bypasscache=true"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsDriverPdate("2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D", time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp, true).Return(res, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"hackLicense":"2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D","trips":10,"medallions":["D7D598CD99978BD012A87A76A7C891B7"]}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
+}
+
+func TestHandler_TripsInArea(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	box := geo.NewBoxArea(geo.Box{Min: geo.Point{Lat: 40.7, Lng: -74}, Max: geo.Point{Lat: 40.8, Lng: -73.9}})
+	radius := geo.NewRadiusArea(geo.Point{Lat: 40.75, Lng: -73.95}, 500)
+	type args struct {
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Missing area",
+			Args:   args{Path: "/trips/v1/area?from=2013-12-01&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Latitude out of range",
+			Args:   args{Path: "/trips/v1/area?lat=91&lng=-73.95&radius=500&date=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid area","instance":"/trips/v1/area","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"area","reason":"invalid area: latitude must be between -90 and 90"}]}`},
+		},
+		{
+			Name:   "Failure - Radius too large",
+			Args:   args{Path: "/trips/v1/area?lat=40.75&lng=-73.95&radius=50001&date=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Box corners swapped",
+			Args:   args{Path: "/trips/v1/area?minlat=40.8&minlng=-74&maxlat=40.7&maxlng=-73.9&date=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Missing dates",
+			Args:   args{Path: "/trips/v1/area?minlat=40.7&minlng=-74&maxlat=40.8&maxlng=-73.9"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name:   "Failure - Invalid permedallion",
+			Args:   args{Path: "/trips/v1/area?minlat=40.7&minlng=-74&maxlat=40.8&maxlng=-73.9&date=2013-12-01&permedallion=maybe"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query count",
+			Args: args{Path: "/trips/v1/area?minlat=40.7&minlng=-74&maxlat=40.8&maxlng=-73.9&from=2013-12-01&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsArea(box, from, to, output.PickUp, []string(nil), false).Return(output.AreaResult{}, errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success with bounding box",
+			Args: args{Path: "/trips/v1/area?minlat=40.7&minlng=-74&maxlat=40.8&maxlng=-73.9&from=2013-12-01&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsArea(box, from, to, output.PickUp, []string(nil), false).Return(output.AreaResult{Trips: 12}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"trips":12}`},
+		},
+		{
+			Name: "Success with drop off basis",
+			Args: args{Path: "/trips/v1/area?minlat=40.7&minlng=-74&maxlat=40.8&maxlng=-73.9&date=2013-12-01&basis=dropoff"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsArea(box, from, from, output.DropOff, []string(nil), false).Return(output.AreaResult{Trips: 7}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"trips":7}`},
+		},
+		{
+			Name: "Success with radius and medallions",
+			Args: args{Path: "/trips/v1/area?lat=40.75&lng=-73.95&radius=500&date=2013-12-01&medallions=D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735&permedallion=true"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsArea(radius, from, from, output.PickUp, []string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, true).
+					Return(output.AreaResult{Trips: 3, Medallions: []output.Result{{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 3}, {Medallion: "5455D5FF2BD94D10B304A15D4B7F2735"}}}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"trips":3,"medallions":[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":3},{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":0}]}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, newYork)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, newYork)
+	first := time.Date(2013, 12, 1, 8, 0, 0, 0, time.UTC)
+	last := time.Date(2013, 12, 30, 22, 0, 0, 0, time.UTC)
+	metrics := output.Metrics{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 2, TotalDistance: 5.5, AverageDistance: 2.75, TotalDuration: 1200, AverageDuration: 600, Passengers: 3, FirstPickUp: &first, LastPickUp: &last}
+	type args struct {
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - From without to",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/metrics?from=2013-12-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Service failed to query metrics",
+			Args: args{Path: "/trips/v1/medallions/0BD7C8F5BA12B88E0B67BED28BEA73D8/metrics"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnMetrics([]string{"0BD7C8F5BA12B88E0B67BED28BEA73D8"}, time.Time{}, time.Time{}, output.PickUp, false).Return([]output.Metrics(nil), errors.New("error"))
+			}},
+			Want: want{Status: http.StatusInternalServerError},
+		},
+		{
+			Name: "Success with date range",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735/metrics?from=2013-12-01&to=2013-12-31&basis=dropoff"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnMetrics([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, from, to, output.DropOff, false).Return([]output.Metrics{metrics, {Medallion: "5455D5FF2BD94D10B304A15D4B7F2735"}}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":2,"totalDistance":5.5,"averageDistance":2.75,"totalDuration":1200,"averageDuration":600,"passengers":3,"firstPickup":"2013-12-01T08:00:00Z","lastPickup":"2013-12-30T22:00:00Z"},` +
+				`{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":0,"totalDistance":0,"averageDistance":0,"totalDuration":0,"averageDuration":0,"passengers":0,"firstPickup":null,"lastPickup":null}]`},
+		},
+		{
+			Name: "Success with opted in fields on pickup date",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-01?fields=duration,pickups"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnMetrics([]string{"D7D598CD99978BD012A87A76A7C891B7"}, from, from, output.PickUp, false).Return([]output.Metrics{metrics}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":2,"totalDuration":1200,"averageDuration":600,"firstPickup":"2013-12-01T08:00:00Z","lastPickup":"2013-12-30T22:00:00Z"}`},
+		},
+		{
+			Name: "Success with opted in fields on pickup date in UTC",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-01?fields=duration&tz=UTC"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				utc := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+				m.OnMetrics([]string{"D7D598CD99978BD012A87A76A7C891B7"}, utc, utc, output.PickUp, false).Return([]output.Metrics{metrics}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":2,"totalDuration":1200,"averageDuration":600}`},
+		},
+		{
+			Name:   "Failure - Invalid tz",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/metrics?from=2013-12-01&to=2013-12-31&tz=Mars/Base"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest},
+		},
+		{
+			Name: "Success with opted in fields on date range",
+			Args: args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-12-01&to=2013-12-31&fields=distance"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnMetrics([]string{"D7D598CD99978BD012A87A76A7C891B7"}, from, to, output.PickUp, false).Return([]output.Metrics{metrics}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":2,"totalDistance":5.5,"averageDistance":2.75}]`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			logger := zap.NewNop()
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = logger
+			mx := wiring.NewRouter(params)
+			ts := httptest.NewServer(mx)
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			if tt.Want.Body != "" {
+				assert.JSONEq(t, tt.Want.Body, string(body), "response")
+			}
+		})
+	}
+}
+
+func TestHandler_Medallions(t *testing.T) {
+	page := output.MedallionPage{
+		Medallions: []output.MedallionSummary{{Medallion: "67EB082BFFE72095EAF18488BEA96050", Trips: 39, FirstSeen: "2013-12-01", LastSeen: "2013-12-31"}},
+		Total:      3,
+		NextOffset: 2,
+	}
+	type args struct {
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockLister)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name:   "Failure - Invalid sort",
+			Args:   args{Path: "/trips/v1/medallions?sort=distance"},
+			Fields: fields{MockExpectations: func(m *mockLister) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid sort","instance":"/trips/v1/medallions","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"sort","reason":"invalid sort value"}]}`},
+		},
+		{
+			Name:   "Failure - Invalid order",
+			Args:   args{Path: "/trips/v1/medallions?order=up"},
+			Fields: fields{MockExpectations: func(m *mockLister) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid order","instance":"/trips/v1/medallions","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"order","reason":"invalid order value"}]}`},
+		},
+		{
+			Name:   "Failure - Negative offset",
+			Args:   args{Path: "/trips/v1/medallions?offset=-1"},
+			Fields: fields{MockExpectations: func(m *mockLister) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid offset","instance":"/trips/v1/medallions","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"offset","reason":"invalid offset"}]}`},
+		},
+		{
+			Name:   "Failure - Limit too large",
+			Args:   args{Path: "/trips/v1/medallions?limit=1001"},
+			Fields: fields{MockExpectations: func(m *mockLister) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid limit","instance":"/trips/v1/medallions","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"limit","reason":"invalid limit"}]}`},
+		},
+		{
+			Name: "Success - Defaults",
+			Args: args{Path: "/trips/v1/medallions"},
+			Fields: fields{MockExpectations: func(m *mockLister) {
+				m.OnMedallions(registry.Options{Sort: registry.ByMedallion, Limit: 100}).Return(output.MedallionPage{Medallions: []output.MedallionSummary{}})
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallions":[],"total":0}`},
+		},
+		{
+			Name: "Success",
+			Args: args{Path: "/trips/v1/medallions?prefix=67e&sort=trips&order=desc&offset=1&limit=1"},
+			Fields: fields{MockExpectations: func(m *mockLister) {
+				m.OnMedallions(registry.Options{Prefix: "67e", Sort: registry.ByTrips, Desc: true, Offset: 1, Limit: 1}).Return(page)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallions":[{"medallion":"67EB082BFFE72095EAF18488BEA96050","trips":39,"firstSeen":"2013-12-01","lastSeen":"2013-12-31"}],"total":3,"nextOffset":2}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockLister
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Registry = &m
+			params.Logger = zap.NewNop()
+			ts := httptest.NewServer(wiring.NewRouter(params))
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			assert.JSONEq(t, tt.Want.Body, string(body), "response")
+		})
+	}
+}
+
+type mockLister struct {
+	mock.Mock
+}
+
+func (m *mockLister) Medallions(ctx context.Context, opts registry.Options) output.MedallionPage {
+	args := m.Called(ctx, opts)
+	return args.Get(0).(output.MedallionPage)
+}
+
+func (m *mockLister) OnMedallions(opts registry.Options) *mock.Call {
+	return m.On("Medallions", mock.AnythingOfType("*context.valueCtx"), opts)
+}
+
+func TestHandler_Problems(t *testing.T) {
+	many := make([]string, 101)
+	for i := range many {
+		many[i] = fmt.Sprintf("%032X", i)
+	}
+	manyPath := "/trips/v1/medallion/" + strings.Join(many, ",") + "/pickupdate/201p-12-31"
+	type args struct {
+		Method string
+		Path   string
+	}
+	type fields struct {
+		MockExpectations func(m *mockClearer)
+	}
+	type want struct {
+		Status      int
+		ContentType string
+		Body        string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Every invalid parameter is listed",
+			Args: args{Method: http.MethodGet, Path: manyPath + "?bypasscache=maybe"},
+			Want: want{Status: http.StatusBadRequest, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid medallions, pickupdate, bypasscache","instance":"` + manyPath + `","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"medallions","reason":"max number of medallions is 100"},{"name":"pickupdate","reason":"invalid pick up date"},{"name":"bypasscache","reason":"invalid bypasscache value"}]}`},
+		},
+		{
+			Name: "Unknown route",
+			Args: args{Method: http.MethodGet, Path: "/trips/v2/medallions"},
+			Want: want{Status: http.StatusNotFound, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/not_found","title":"Not Found","status":404,"detail":"no resource at /trips/v2/medallions","instance":"/trips/v2/medallions","requestId":"test-request","code":"not_found"}`},
+		},
+		{
+			Name: "Method not allowed",
+			Args: args{Method: http.MethodPost, Path: "/trips/v1/leaderboard"},
+			Want: want{Status: http.StatusMethodNotAllowed, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/method_not_allowed","title":"Method Not Allowed","status":405,"detail":"POST is not allowed at /trips/v1/leaderboard","instance":"/trips/v1/leaderboard","requestId":"test-request","code":"method_not_allowed"}`},
+		},
+		{
+			Name: "Cache cleared",
+			Args: args{Method: http.MethodDelete, Path: "/trips/v1/cache/contents"},
+			Fields: fields{MockExpectations: func(m *mockClearer) {
+				m.On("Clear", mock.AnythingOfType("*context.valueCtx")).Return(3)
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `{"message":"cache cleared","evicted":3}`},
+		},
+		{
+			Name: "Cache entries of medallion invalidated",
+			Args: args{Method: http.MethodDelete, Path: "/trips/v1/cache/contents?medallion=d7d598cd99978bd012a87a76a7c891b7"},
+			Fields: fields{MockExpectations: func(m *mockClearer) {
+				m.On("Invalidate", mock.AnythingOfType("*context.valueCtx"), cache.Selector{Medallion: "D7D598CD99978BD012A87A76A7C891B7"}).Return(4)
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `{"message":"cache entries invalidated","evicted":4}`},
+		},
+		{
+			Name: "Cache entries of medallion in date range invalidated",
+			Args: args{Method: http.MethodDelete, Path: "/trips/v1/cache/contents?medallion=D7D598CD99978BD012A87A76A7C891B7&from=2013-12-01&to=2013-12-31"},
+			Fields: fields{MockExpectations: func(m *mockClearer) {
+				sel := cache.Selector{Medallion: "D7D598CD99978BD012A87A76A7C891B7", From: time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)}
+				m.On("Invalidate", mock.AnythingOfType("*context.valueCtx"), sel).Return(2)
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `{"message":"cache entries invalidated","evicted":2}`},
+		},
+		{
+			Name: "Cache entries by prefix invalidated",
+			Args: args{Method: http.MethodDelete, Path: "/trips/v1/cache/contents?prefix=ranking:"},
+			Fields: fields{MockExpectations: func(m *mockClearer) {
+				m.On("Invalidate", mock.AnythingOfType("*context.valueCtx"), cache.Selector{Prefix: "ranking:"}).Return(0)
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `{"message":"cache entries invalidated","evicted":0}`},
+		},
+		{
+			Name: "Invalidating dates without medallion",
+			Args: args{Method: http.MethodDelete, Path: "/trips/v1/cache/contents?from=2013-12-01"},
+			Want: want{Status: http.StatusBadRequest, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid medallion","instance":"/trips/v1/cache/contents","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"medallion","reason":"dates select the entries of a medallion"}]}`},
+		},
+		{
+			Name: "Invalidating invalid dates",
+			Args: args{Method: http.MethodDelete, Path: "/trips/v1/cache/contents?medallion=D7D598CD99978BD012A87A76A7C891B7&from=2013-12-31&to=2013-12-01"},
+			Want: want{Status: http.StatusBadRequest, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid from","instance":"/trips/v1/cache/contents","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"from","reason":"from date is after to date"}]}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var c mockClearer
+			if tt.Fields.MockExpectations != nil {
+				tt.Fields.MockExpectations(&c)
+			}
+			params := new(wiring.Params)
+			params.Svc = new(mockTripSvc)
+			params.Cache = &c
+			params.Logger = zap.NewNop()
+			ts := httptest.NewServer(wiring.NewRouter(params))
+			defer ts.Close()
+			res, err := send(tt.Args.Method, ts.URL+tt.Args.Path, nil)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			c.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			assert.Equal(t, tt.Want.ContentType, res.Header.Get("Content-Type"), "content type")
+			assert.Equal(t, requestID, res.Header.Get("X-Request-ID"), "request id")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			assert.JSONEq(t, tt.Want.Body, string(body), "response")
+		})
+	}
+}
+
+func TestHandler_RequestID(t *testing.T) {
+	params := new(wiring.Params)
+	params.Svc = new(mockTripSvc)
+	params.Logger = zap.NewNop()
+	ts := httptest.NewServer(wiring.NewRouter(params))
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/trips/v1/leaderboard?limit=0")
+	assert.NoError(t, err, "Error executing request")
+	defer res.Body.Close()
+	var problem handler.Problem
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&problem), "Error reading response")
+	assert.Len(t, problem.RequestID, 32, "generated request id")
+	assert.Equal(t, problem.RequestID, res.Header.Get("X-Request-ID"), "request id")
+}
+
+func TestHandler_Validation(t *testing.T) {
+	bounds, err := validation.NewBounds("2013-12-01", "2013-12-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type args struct {
+		Path string
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Status int
+		Body   string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Medallions are normalized and deduplicated",
+			Args: args{Path: "/trips/v1/medallions/d7d598cd99978bd012a87a76a7c891b7,D7D598CD99978BD012A87A76A7C891B7,5455D5FF2BD94D10B304A15D4B7F2735"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsTripsByMedallion([]string{"D7D598CD99978BD012A87A76A7C891B7", "5455D5FF2BD94D10B304A15D4B7F2735"}, false).Return([]output.Result{{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}, {Medallion: "5455D5FF2BD94D10B304A15D4B7F2735", Trips: 3}}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `[{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10},{"medallion":"5455D5FF2BD94D10B304A15D4B7F2735","trips":3}]`},
+		},
+		{
+			Name:   "Failure - Not a medallion",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7,YYYY"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid medallions","instance":"/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7,YYYY","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"medallions","reason":"not 32 character hex medallions: YYYY"}]}`},
+		},
+		{
+			Name:   "Failure - Empty medallion",
+			Args:   args{Path: "/trips/v1/medallions/,"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid medallions","instance":"/trips/v1/medallions/,","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"medallions","reason":"missing medallions"}]}`},
+		},
+		{
+			Name:   "Failure - Not a medallion of trips",
+			Args:   args{Path: "/trips/v1/medallion/junk/trips"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid medallion","instance":"/trips/v1/medallion/junk/trips","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"medallion","reason":"junk is not a 32 character hex medallion"}]}`},
+		},
+		{
+			Name:   "Failure - Pick up date out of the dataset",
+			Args:   args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2014-01-01"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid pickupdate","instance":"/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2014-01-01","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"pickupdate","reason":"date must be between 2013-12-01 and 2013-12-31"}]}`},
+		},
+		{
+			Name:   "Failure - Date range out of the dataset",
+			Args:   args{Path: "/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups?from=2013-11-01&to=2014-01-31"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Status: http.StatusBadRequest, Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid from, to","instance":"/trips/v1/medallions/D7D598CD99978BD012A87A76A7C891B7/pickups","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"from","reason":"date must be between 2013-12-01 and 2013-12-31"},{"name":"to","reason":"date must be between 2013-12-01 and 2013-12-31"}]}`},
+		},
+		{
+			Name: "Success within the dataset",
+			Args: args{Path: "/trips/v1/medallion/D7D598CD99978BD012A87A76A7C891B7/pickupdate/2013-12-31?tz=UTC"},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnTripsByPickUpDate([]string{"D7D598CD99978BD012A87A76A7C891B7"}, time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC), output.PickUp, false).Return([]output.Result{{Medallion: "D7D598CD99978BD012A87A76A7C891B7", Trips: 10}}, nil)
+			}},
+			Want: want{Status: http.StatusOK, Body: `{"medallion":"D7D598CD99978BD012A87A76A7C891B7","trips":10}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			params := new(wiring.Params)
+			params.Svc = &m
+			params.Logger = zap.NewNop()
+			params.Bounds = bounds
+			ts := httptest.NewServer(wiring.NewRouter(params))
+			defer ts.Close()
+			res, err := get(ts.URL + tt.Args.Path)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			assert.JSONEq(t, tt.Want.Body, string(body), "response")
+		})
+	}
+}
+
+// get sends a GET request with a fixed request ID.
+func get(url string) (*http.Response, error) {
+	return send(http.MethodGet, url, nil)
+}
+
+// send sends a request with a fixed request ID.
+func TestHandler_CacheInspection(t *testing.T) {
+	ttl := 3600.0
+	age := 12.5
+	type args struct {
+		Path          string
+		Authorization string
+		AdminToken    string
+	}
+	type fields struct {
+		MockExpectations func(m *mockInspector)
+	}
+	type want struct {
+		Status          int
+		ContentType     string
+		WWWAuthenticate string
+		Body            string
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Cache stats",
+			Args: args{Path: "/trips/v1/cache/stats"},
+			Fields: fields{MockExpectations: func(m *mockInspector) {
+				m.On("Stats", mock.AnythingOfType("*context.valueCtx")).Return(output.CacheStats{Backend: "memory", Entries: 2, Hits: 3, Misses: 1, HitRatio: 0.75, Evictions: 4, Bytes: 512})
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `{"backend":"memory","entries":2,"hits":3,"misses":1,"hitRatio":0.75,"evictions":4,"approxBytes":512}`},
+		},
+		{
+			Name: "Cache entries",
+			Args: args{Path: "/trips/v1/cache/entries?prefix=total:&limit=5", Authorization: "Bearer secret", AdminToken: "secret"},
+			Fields: fields{MockExpectations: func(m *mockInspector) {
+				m.On("Entries", mock.AnythingOfType("*context.valueCtx"), "total:", 5).
+					Return([]output.CacheEntry{{Key: "total:MED1", Value: 5, Age: &age, TTL: &ttl}, {Key: "total:MED2", Value: 0, Age: &age}})
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `[{"key":"total:MED1","value":5,"ageSeconds":12.5,"ttlSeconds":3600},{"key":"total:MED2","value":0,"ageSeconds":12.5,"ttlSeconds":null}]`},
+		},
+		{
+			Name: "Cache entries with default limit",
+			Args: args{Path: "/trips/v1/cache/entries", Authorization: "Bearer secret", AdminToken: "secret"},
+			Fields: fields{MockExpectations: func(m *mockInspector) {
+				m.On("Entries", mock.AnythingOfType("*context.valueCtx"), "", 100).Return([]output.CacheEntry{})
+			}},
+			Want: want{Status: http.StatusOK, ContentType: "application/json; charset=UTF-8", Body: `[]`},
+		},
+		{
+			Name: "Cache entries with invalid limit",
+			Args: args{Path: "/trips/v1/cache/entries?limit=1001", Authorization: "Bearer secret", AdminToken: "secret"},
+			Want: want{Status: http.StatusBadRequest, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/invalid_input","title":"Bad Request","status":400,"detail":"invalid limit","instance":"/trips/v1/cache/entries","requestId":"test-request","code":"invalid_input","invalidParams":[{"name":"limit","reason":"invalid limit"}]}`},
+		},
+		{
+			Name: "Cache entries without admin token",
+			Args: args{Path: "/trips/v1/cache/entries", AdminToken: "secret"},
+			Want: want{Status: http.StatusUnauthorized, ContentType: "application/problem+json; charset=UTF-8", WWWAuthenticate: `Bearer realm="admin"`, Body: `{"type":"/problems/unauthorized","title":"Unauthorized","status":401,"detail":"admin token required","instance":"/trips/v1/cache/entries","requestId":"test-request","code":"unauthorized"}`},
+		},
+		{
+			Name: "Cache entries with wrong admin token",
+			Args: args{Path: "/trips/v1/cache/entries", Authorization: "Bearer guess", AdminToken: "secret"},
+			Want: want{Status: http.StatusUnauthorized, ContentType: "application/problem+json; charset=UTF-8", WWWAuthenticate: `Bearer realm="admin"`, Body: `{"type":"/problems/unauthorized","title":"Unauthorized","status":401,"detail":"admin token required","instance":"/trips/v1/cache/entries","requestId":"test-request","code":"unauthorized"}`},
+		},
+		{
+			Name: "Cache entries while admin endpoints are disabled",
+			Args: args{Path: "/trips/v1/cache/entries", Authorization: "Bearer "},
+			Want: want{Status: http.StatusForbidden, ContentType: "application/problem+json; charset=UTF-8", Body: `{"type":"/problems/forbidden","title":"Forbidden","status":403,"detail":"admin endpoints are disabled","instance":"/trips/v1/cache/entries","requestId":"test-request","code":"forbidden"}`},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockInspector
+			if tt.Fields.MockExpectations != nil {
+				tt.Fields.MockExpectations(&m)
+			}
+			params := new(wiring.Params)
+			params.Svc = new(mockTripSvc)
+			params.Inspector = &m
+			params.AdminToken = tt.Args.AdminToken
+			params.Logger = zap.NewNop()
+			ts := httptest.NewServer(wiring.NewRouter(params))
+			defer ts.Close()
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.Args.Path, nil)
+			assert.NoError(t, err, "Error creating request")
+			req.Header.Set("X-Request-ID", requestID)
+			if len(tt.Args.Authorization) > 0 {
+				req.Header.Set("Authorization", tt.Args.Authorization)
+			}
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err, "Error executing request")
+			defer res.Body.Close()
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Status, res.StatusCode, "status")
+			assert.Equal(t, tt.Want.ContentType, res.Header.Get("Content-Type"), "content type")
+			assert.Equal(t, tt.Want.WWWAuthenticate, res.Header.Get("WWW-Authenticate"), "authenticate")
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err, "Error reading response")
+			assert.JSONEq(t, tt.Want.Body, string(body), "response")
+		})
+	}
+}
+
+func send(method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Request-ID", requestID)
+	return http.DefaultClient.Do(req)
+}
+
+type mockClearer struct {
+	mock.Mock
+}
+
+func (m *mockClearer) Clear(ctx context.Context) int {
+	args := m.Called(ctx)
+	return args.Int(0)
+}
+
+func (m *mockClearer) Invalidate(ctx context.Context, sel cache.Selector) int {
+	args := m.Called(ctx, sel)
+	return args.Int(0)
+}
+
+type mockInspector struct {
+	mock.Mock
+}
+
+func (m *mockInspector) Stats(ctx context.Context) output.CacheStats {
+	args := m.Called(ctx)
+	return args.Get(0).(output.CacheStats)
+}
+
+func (m *mockInspector) Entries(ctx context.Context, prefix string, limit int) []output.CacheEntry {
+	args := m.Called(ctx, prefix, limit)
+	return args.Get(0).([]output.CacheEntry)
+}
+
+type mockTripSvc struct {
+	mock.Mock
+}
+
+func (m *mockTripSvc) TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, pickUpDate, basis, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsByPickUpDate(medallions []string, pickUpDate time.Time, basis output.Basis, byPassCache bool) *mock.Call {
+	return m.On("TripsByMedallionsOnPickUpDate", mock.AnythingOfType("*context.valueCtx"), medallions, pickUpDate, basis, byPassCache)
+}
+
+func (m *mockTripSvc) TripsByMedallion(ctx context.Context, medallions []string, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsTripsByMedallion(medallions []string, byPassCache bool) *mock.Call {
+	return m.On("TripsByMedallion", mock.AnythingOfType("*context.valueCtx"), medallions, byPassCache)
+}
+
+func (m *mockTripSvc) TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, from, to, basis, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsByDateRange(medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) *mock.Call {
+	return m.On("TripsByMedallionsInDateRange", mock.AnythingOfType("*context.valueCtx"), medallions, from, to, basis, byPassCache)
+}
+
+func (m *mockTripSvc) TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.DailyResult, error) {
+	args := m.Called(ctx, medallion, from, to, basis, byPassCache)
+	return args.Get(0).([]output.DailyResult), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsPerDay(medallion string, from, to time.Time, basis output.Basis, byPassCache bool) *mock.Call {
+	return m.On("TripsByMedallionPerDay", mock.AnythingOfType("*context.valueCtx"), medallion, from, to, basis, byPassCache)
+}
+
+func (m *mockTripSvc) PickUpHistogram(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (output.Histogram, error) {
+	args := m.Called(ctx, medallions, from, to, basis)
+	return args.Get(0).(output.Histogram), args.Error(1)
+}
+
+func (m *mockTripSvc) OnHistogram(medallions []string, from, to time.Time, basis output.Basis) *mock.Call {
+	return m.On("PickUpHistogram", mock.AnythingOfType("*context.valueCtx"), medallions, from, to, basis)
+}
+
+func (m *mockTripSvc) StreamTripsByMedallions(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool, fn func([]output.Result) error) error {
+	args := m.Called(ctx, medallions, from, to, basis, byPassCache)
+	if chunks, ok := args.Get(1).([][]output.Result); ok {
+		for _, chunk := range chunks {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(0)
+}
+
+// OnStreamTrips expects a streamed query answered with the given chunks before returning.
+func (m *mockTripSvc) OnStreamTrips(medallions []string, from, to time.Time, basis output.Basis, byPassCache bool, chunks ...[]output.Result) *streamCall {
+	return &streamCall{call: m.On("StreamTripsByMedallions", mock.AnythingOfType("*context.valueCtx"), medallions, from, to, basis, byPassCache), chunks: chunks}
+}
+
+type streamCall struct {
+	call   *mock.Call
+	chunks [][]output.Result
+}
+
+func (c *streamCall) Return(err error) *mock.Call {
+	return c.call.Return(err, c.chunks)
+}
+
+func (m *mockTripSvc) Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) (output.TripPage, error) {
+	args := m.Called(ctx, medallion, from, to, basis, after, limit)
+	return args.Get(0).(output.TripPage), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTrips(medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) *mock.Call {
+	return m.On("Trips", mock.AnythingOfType("*context.valueCtx"), medallion, from, to, basis, after, limit)
+}
+
+func (m *mockTripSvc) TopMedallions(ctx context.Context, from, to time.Time, basis output.Basis, limit int, byPassCache bool) ([]output.RankedResult, error) {
+	args := m.Called(ctx, from, to, basis, limit, byPassCache)
+	return args.Get(0).([]output.RankedResult), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTop(from, to time.Time, basis output.Basis, limit int, byPassCache bool) *mock.Call {
+	return m.On("TopMedallions", mock.AnythingOfType("*context.valueCtx"), from, to, basis, limit, byPassCache)
+}
+
+func (m *mockTripSvc) TripsByDriver(ctx context.Context, licenses []string, byPassCache bool) ([]output.DriverResult, error) {
+	args := m.Called(ctx, licenses, byPassCache)
+	return args.Get(0).([]output.DriverResult), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsDriver(licenses []string, byPassCache bool) *mock.Call {
+	return m.On("TripsByDriver", mock.AnythingOfType("*context.valueCtx"), licenses, byPassCache)
+}
+
+func (m *mockTripSvc) TripsByDriverOnPickUpDate(ctx context.Context, license string, pickUpDate time.Time, basis output.Basis, byPassCache bool) (output.DriverResult, error) {
+	args := m.Called(ctx, license, pickUpDate, basis, byPassCache)
+	return args.Get(0).(output.DriverResult), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsDriverPdate(license string, pickUpDate time.Time, basis output.Basis, byPassCache bool) *mock.Call {
+	return m.On("TripsByDriverOnPickUpDate", mock.AnythingOfType("*context.valueCtx"), license, pickUpDate, basis, byPassCache)
+}
+
+func (m *mockTripSvc) TripsInArea(ctx context.Context, area geo.Area, from, to time.Time, basis output.Basis, medallions []string, perMedallion bool) (output.AreaResult, error) {
+	args := m.Called(ctx, area, from, to, basis, medallions, perMedallion)
+	return args.Get(0).(output.AreaResult), args.Error(1)
+}
+
+func (m *mockTripSvc) OnTripsArea(area geo.Area, from, to time.Time, basis output.Basis, medallions []string, perMedallion bool) *mock.Call {
+	return m.On("TripsInArea", mock.AnythingOfType("*context.valueCtx"), area, from, to, basis, medallions, perMedallion)
+}
+
+func (m *mockTripSvc) Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Metrics, error) {
+	args := m.Called(ctx, medallions, from, to, basis, byPassCache)
+	return args.Get(0).([]output.Metrics), args.Error(1)
+}
+
+func (m *mockTripSvc) OnMetrics(medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) *mock.Call {
+	return m.On("Metrics", mock.AnythingOfType("*context.valueCtx"), medallions, from, to, basis, byPassCache)
 }
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+)
+
+// Error codes of Problem.
+const (
+	// CodeInvalidInput is a request with invalid parameters, status 400.
+	CodeInvalidInput = "invalid_input"
+	// CodeUnauthorized is a request without valid admin credentials, status 401.
+	CodeUnauthorized = "unauthorized"
+	// CodeForbidden is an admin request while no admin token is configured, status 403.
+	CodeForbidden = "forbidden"
+	// CodeNotFound is an unknown resource, status 404.
+	CodeNotFound = "not_found"
+	// CodeMethodNotAllowed is a known resource requested with an unsupported method, status 405.
+	CodeMethodNotAllowed = "method_not_allowed"
+	// CodeUnavailable is a database that can not be reached, status 503.
+	CodeUnavailable = "db_unavailable"
+	// CodeTimeout is a query that ran out of time, status 504.
+	CodeTimeout = "query_timeout"
+	// CodeCanceled is a request canceled by the client, status 499.
+	CodeCanceled = "request_canceled"
+	// CodeInternal is any other failure, status 500.
+	CodeInternal = "internal_error"
+)
+
+// StatusClientClosedRequest is the non standard status of a request canceled by the client.
+const StatusClientClosedRequest = 499
+
+const (
+	// ProblemContentType is the media type of a Problem.
+	ProblemContentType = "application/problem+json"
+	// problemTypeBase prefixes the code of a problem to form its type URI.
+	problemTypeBase = "/problems/"
+	// RequestIDHeader carries the request ID, it is reused when the client supplies one.
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength is the longest request ID accepted from a client.
+	maxRequestIDLength = 128
+)
+
+// Problem represents an error response as described by RFC 7807.
+// Code is a stable machine readable error code, Detail is for humans and may change.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance"`
+	RequestID     string         `json:"requestId"`
+	Code          string         `json:"code"`
+	InvalidParams []InvalidParam `json:"invalidParams,omitempty"`
+}
+
+// InvalidParam represents a request parameter that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// NewProblem creates the problem of status and code for the request r.
+func NewProblem(r *http.Request, status int, code, detail string) *Problem {
+	return &Problem{
+		Type:      problemTypeBase + code,
+		Title:     statusTitle(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: RequestIDOf(r.Context()),
+		Code:      code,
+	}
+}
+
+func statusTitle(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+// InvalidParams collects every invalid parameter of a request.
+type InvalidParams []InvalidParam
+
+// Add lists the reason name is invalid.
+func (p *InvalidParams) Add(name, reason string) {
+	*p = append(*p, InvalidParam{Name: name, Reason: reason})
+}
+
+// AddErr lists err under the field of an invalid input error, under name otherwise.
+func (p *InvalidParams) AddErr(name string, err error) {
+	if invalid, ok := err.(*failure.InvalidError); ok {
+		p.Add(invalid.Field, invalid.Reason)
+		return
+	}
+	p.Add(name, err.Error())
+}
+
+// AddAs lists the reason of err under name, the parameter the invalid value was found in.
+func (p *InvalidParams) AddAs(name string, err error) {
+	if invalid, ok := err.(*failure.InvalidError); ok {
+		p.Add(name, invalid.Reason)
+		return
+	}
+	p.Add(name, err.Error())
+}
+
+// Check lists err when it is not nil.
+func (p *InvalidParams) Check(err error) {
+	if err != nil {
+		p.AddErr("", err)
+	}
+}
+
+// Names lists the name of each invalid parameter.
+func (p InvalidParams) Names() []string {
+	names := make([]string, 0, len(p))
+	for _, param := range p {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+// responseBadRequest responds with a problem listing every invalid parameter.
+func responseBadRequest(w http.ResponseWriter, r *http.Request, invalid InvalidParams) {
+	problem := NewProblem(r, http.StatusBadRequest, CodeInvalidInput, "invalid "+strings.Join(invalid.Names(), ", "))
+	problem.InvalidParams = invalid
+	responseProblem(w, problem)
+}
+
+// responseFailure responds with the problem err stands for.
+func responseFailure(w http.ResponseWriter, r *http.Request, err error) {
+	responseProblem(w, failureOf(r, err))
+}
+
+func responseProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", ProblemContentType+"; charset=UTF-8")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// failureOf maps err to a problem, unclassified errors are internal errors.
+func failureOf(r *http.Request, err error) *Problem {
+	status, code, detail := Classify(err)
+	return NewProblem(r, status, code, detail)
+}
+
+// Classify returns the status, the error code and the detail of the problem err stands for,
+// unclassified errors are internal errors.
+func Classify(err error) (int, string, string) {
+	switch {
+	case failure.Is(err, failure.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound, "not found"
+	case failure.Is(err, failure.ErrInvalidInput):
+		return http.StatusBadRequest, CodeInvalidInput, "invalid input"
+	case failure.Is(err, failure.ErrUnavailable):
+		return http.StatusServiceUnavailable, CodeUnavailable, "database unavailable"
+	case failure.Is(err, failure.ErrTimeout):
+		return http.StatusGatewayTimeout, CodeTimeout, "query timeout"
+	case failure.Is(err, failure.ErrCanceled):
+		return StatusClientClosedRequest, CodeCanceled, "request canceled"
+	default:
+		return http.StatusInternalServerError, CodeInternal, "service failure"
+	}
+}
+
+// NotFound responds with a problem for requests matching no route.
+func NotFound(logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("no route", zap.String("path", r.URL.Path))
+		responseProblem(w, NewProblem(r, http.StatusNotFound, CodeNotFound, "no resource at "+r.URL.Path))
+	}
+}
+
+// MethodNotAllowed responds with a problem for requests matching a route but not its method.
+func MethodNotAllowed(logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("method not allowed", zap.String("method", r.Method), zap.String("path", r.URL.Path))
+		responseProblem(w, NewProblem(r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed at "+r.URL.Path))
+	}
+}
+
+type requestIDKey struct{}
+
+// RequestID tags each request with the ID supplied in the X-Request-ID header or a new one
+// and echoes it in the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if len(id) == 0 || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDOf returns the ID RequestID tagged the request with, empty when untagged.
+func RequestIDOf(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
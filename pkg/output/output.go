@@ -1,7 +1,241 @@
 package output
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+)
+
 // Result represents output response.
+// Status is only reported by the endpoints listing every requested medallion.
 type Result struct {
 	Medallion string `json:"medallion"`
 	Trips     int    `json:"trips"`
+	Status    Status `json:"status,omitempty"`
+}
+
+// Status tells medallions without trips apart from medallions never seen.
+type Status string
+
+const (
+	// Known medallions have trips, though maybe none in the requested dates.
+	Known Status = "known"
+	// Unknown medallions have no trips at all.
+	Unknown Status = "unknown"
+)
+
+// Metrics represents the trip figures of a medallion.
+// First and last pick up are nil when the medallion made no trips.
+type Metrics struct {
+	Medallion       string     `json:"medallion" db:"medallion"`
+	Trips           int        `json:"trips" db:"trips"`
+	TotalDistance   float64    `json:"totalDistance" db:"total_distance"`
+	AverageDistance float64    `json:"averageDistance" db:"average_distance"`
+	TotalDuration   int        `json:"totalDuration" db:"total_duration"`
+	AverageDuration float64    `json:"averageDuration" db:"average_duration"`
+	Passengers      int        `json:"passengers" db:"passengers"`
+	FirstPickUp     *time.Time `json:"firstPickup" db:"first_pickup"`
+	LastPickUp      *time.Time `json:"lastPickup" db:"last_pickup"`
+}
+
+// Field is a group of metrics a trip count can be extended with.
+type Field string
+
+const (
+	// Distance adds total and average trip distance.
+	Distance Field = "distance"
+	// Duration adds total and average trip time in seconds.
+	Duration Field = "duration"
+	// Passengers adds total passengers.
+	Passengers Field = "passengers"
+	// PickUps adds first and last pick up time.
+	PickUps Field = "pickups"
+)
+
+// ExtendedResult represents trips made by a medallion along with the requested metrics.
+type ExtendedResult struct {
+	Result
+	TotalDistance   *float64   `json:"totalDistance,omitempty"`
+	AverageDistance *float64   `json:"averageDistance,omitempty"`
+	TotalDuration   *int       `json:"totalDuration,omitempty"`
+	AverageDuration *float64   `json:"averageDuration,omitempty"`
+	Passengers      *int       `json:"passengers,omitempty"`
+	FirstPickUp     *time.Time `json:"firstPickup,omitempty"`
+	LastPickUp      *time.Time `json:"lastPickup,omitempty"`
+}
+
+// NewExtendedResult keeps the trips and the metrics of fields.
+func NewExtendedResult(m Metrics, fields []Field) ExtendedResult {
+	res := ExtendedResult{Result: Result{Medallion: m.Medallion, Trips: m.Trips}}
+	for _, f := range fields {
+		switch f {
+		case Distance:
+			res.TotalDistance, res.AverageDistance = &m.TotalDistance, &m.AverageDistance
+		case Duration:
+			res.TotalDuration, res.AverageDuration = &m.TotalDuration, &m.AverageDuration
+		case Passengers:
+			res.Passengers = &m.Passengers
+		case PickUps:
+			res.FirstPickUp, res.LastPickUp = m.FirstPickUp, m.LastPickUp
+		}
+	}
+	return res
+}
+
+// DriverResult represents trips made by a driver and the medallions the driver operated.
+type DriverResult struct {
+	HackLicense string   `json:"hackLicense"`
+	Trips       int      `json:"trips"`
+	Medallions  []string `json:"medallions"`
+}
+
+// AreaResult represents trips inside an area, in total and optionally per medallion.
+type AreaResult struct {
+	Trips      int      `json:"trips"`
+	Medallions []Result `json:"medallions,omitempty"`
+}
+
+// RankedResult represents trips made by a medallion and its rank by trips.
+type RankedResult struct {
+	Rank      int    `json:"rank"`
+	Medallion string `json:"medallion"`
+	Trips     int    `json:"trips"`
+}
+
+// MedallionSummary represents a known medallion with its total trips and first and last pick up dates.
+type MedallionSummary struct {
+	Medallion string `json:"medallion" db:"medallion"`
+	Trips     int    `json:"trips" db:"trips"`
+	FirstSeen string `json:"firstSeen" db:"first_seen"`
+	LastSeen  string `json:"lastSeen" db:"last_seen"`
+}
+
+// MedallionPage represents a page of medallions, the number of matching medallions and the offset of the next page.
+type MedallionPage struct {
+	Medallions []MedallionSummary `json:"medallions"`
+	Total      int                `json:"total"`
+	NextOffset int                `json:"nextOffset,omitempty"`
+}
+
+// DailyResult represents trips made on a single pick up date.
+type DailyResult struct {
+	Date  string `json:"date"`
+	Trips int    `json:"trips"`
+}
+
+// Histogram represents trips by hour of day (0-23) and day of week (0 is Sunday) of pick up.
+type Histogram struct {
+	Medallions []string `json:"medallions"`
+	HourOfDay  [24]int  `json:"hourOfDay"`
+	DayOfWeek  [7]int   `json:"dayOfWeek"`
+}
+
+// CacheStats represents the use of the cache since start up.
+// Evictions counts the entries evicted by the max entries bound, expiry, invalidation or clearing.
+// Bytes approximates the memory held by the entries.
+type CacheStats struct {
+	Backend   string  `json:"backend"`
+	Entries   int     `json:"entries"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitRatio  float64 `json:"hitRatio"`
+	Evictions int64   `json:"evictions"`
+	Bytes     int64   `json:"approxBytes"`
+}
+
+// CacheEntry represents a cache entry with its age and remaining time to live in seconds.
+// Age is null when unknown and TTL is null for entries that never expire.
+type CacheEntry struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Age   *float64    `json:"ageSeconds"`
+	TTL   *float64    `json:"ttlSeconds"`
+}
+
+// Basis selects whether trips are counted by their pick up or their drop off.
+type Basis string
+
+const (
+	// PickUp counts trips by pick up date time and location.
+	PickUp Basis = "pickup"
+	// DropOff counts trips by drop off date time and location.
+	DropOff Basis = "dropoff"
+)
+
+// Trip represents a single cab trip.
+type Trip struct {
+	Medallion        string    `json:"medallion" db:"medallion"`
+	PickUpDateTime   time.Time `json:"pickupDatetime" db:"pickup_datetime"`
+	DropOffDateTime  time.Time `json:"dropoffDatetime" db:"dropoff_datetime"`
+	PassengerCount   int       `json:"passengerCount" db:"passenger_count"`
+	TripTimeInSecs   int       `json:"tripTimeInSecs" db:"trip_time_in_secs"`
+	TripDistance     float64   `json:"tripDistance" db:"trip_distance"`
+	PickUpLongitude  float64   `json:"pickupLongitude" db:"pickup_longitude"`
+	PickUpLatitude   float64   `json:"pickupLatitude" db:"pickup_latitude"`
+	DropOffLongitude float64   `json:"dropoffLongitude" db:"dropoff_longitude"`
+	DropOffLatitude  float64   `json:"dropoffLatitude" db:"dropoff_latitude"`
+}
+
+// TripPage represents a page of trips and the cursor to the next page.
+type TripPage struct {
+	Trips      []Trip `json:"trips"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Cursor is the position after the last trip of a page, ordered by pick up then drop off time
+// or by drop off then pick up time for the drop off basis.
+// Trips sharing both date times share a position, Skip is the number of them already listed.
+type Cursor struct {
+	PickUpDateTime  time.Time `json:"p"`
+	DropOffDateTime time.Time `json:"d"`
+	Skip            int       `json:"s"`
+}
+
+// NewCursor creates the cursor positioned after trip.
+func NewCursor(trip Trip) Cursor {
+	return Cursor{PickUpDateTime: trip.PickUpDateTime, DropOffDateTime: trip.DropOffDateTime, Skip: 1}
+}
+
+// NextCursor creates the cursor positioned after the last trip of page, the page following after.
+// The trips of page sharing the position of the last trip are skipped, along with those of previous pages
+// when every trip of page shares the position of after.
+func NextCursor(page []Trip, after *Cursor) Cursor {
+	c := NewCursor(page[len(page)-1])
+	for i := len(page) - 2; i >= 0 && c.at(page[i]); i-- {
+		c.Skip++
+	}
+	if after != nil && c.Skip == len(page) && after.PickUpDateTime.Equal(c.PickUpDateTime) && after.DropOffDateTime.Equal(c.DropOffDateTime) {
+		c.Skip += after.Skip
+	}
+	return c
+}
+
+// at reports whether trip is at the position of the cursor.
+func (c Cursor) at(trip Trip) bool {
+	return trip.PickUpDateTime.Equal(c.PickUpDateTime) && trip.DropOffDateTime.Equal(c.DropOffDateTime)
+}
+
+// Encode returns the opaque form of the cursor.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses an opaque cursor created by Encode.
+// A cursor positioned after no trip is invalid input.
+func DecodeCursor(val string) (Cursor, error) {
+	var c Cursor
+	b, err := base64.RawURLEncoding.DecodeString(val)
+	if err != nil {
+		return Cursor{}, failure.Invalid("cursor", "malformed cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.PickUpDateTime.IsZero() {
+		return Cursor{}, failure.Invalid("cursor", "malformed cursor")
+	}
+	if c.Skip < 1 {
+		return Cursor{}, failure.Invalid("cursor", "cursor skip must be at least 1")
+	}
+	return c, nil
 }
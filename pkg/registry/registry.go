@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+// Sort is the order medallions are listed in.
+type Sort string
+
+const (
+	// ByMedallion sorts by medallion.
+	ByMedallion Sort = "medallion"
+	// ByTrips sorts by total trips.
+	ByTrips Sort = "trips"
+	// ByFirstSeen sorts by first pick up date.
+	ByFirstSeen Sort = "firstseen"
+	// ByLastSeen sorts by last pick up date.
+	ByLastSeen Sort = "lastseen"
+)
+
+// ParseSort defaults to medallion when val is empty.
+func ParseSort(val string) (Sort, error) {
+	switch s := Sort(strings.ToLower(val)); s {
+	case "":
+		return ByMedallion, nil
+	case ByMedallion, ByTrips, ByFirstSeen, ByLastSeen:
+		return s, nil
+	default:
+		return "", fmt.Errorf("sort must be one of %s, %s, %s or %s", ByMedallion, ByTrips, ByFirstSeen, ByLastSeen)
+	}
+}
+
+// Options narrow and page the listed medallions.
+type Options struct {
+	Prefix string
+	Sort   Sort
+	Desc   bool
+	Offset int
+	Limit  int
+}
+
+// Loader provides the summary of every medallion.
+type Loader interface {
+	MedallionSummaries(ctx context.Context) ([]output.MedallionSummary, error)
+}
+
+// Registry is an in memory index of the known medallions.
+// Summaries are kept presorted per sort order so a page is a slice of the index.
+type Registry struct {
+	sorted map[Sort][]output.MedallionSummary
+}
+
+// Load builds the registry from the summaries of loader.
+func Load(ctx context.Context, loader Loader) (*Registry, error) {
+	summaries, err := loader.MedallionSummaries(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load medallions")
+	}
+	return New(summaries), nil
+}
+
+// New indexes summaries.
+func New(summaries []output.MedallionSummary) *Registry {
+	byMedallion := make([]output.MedallionSummary, len(summaries))
+	copy(byMedallion, summaries)
+	sort.Slice(byMedallion, func(i, j int) bool { return byMedallion[i].Medallion < byMedallion[j].Medallion })
+
+	r := &Registry{sorted: map[Sort][]output.MedallionSummary{ByMedallion: byMedallion}}
+	r.sorted[ByTrips] = sortedBy(byMedallion, func(a, b output.MedallionSummary) bool { return a.Trips < b.Trips })
+	r.sorted[ByFirstSeen] = sortedBy(byMedallion, func(a, b output.MedallionSummary) bool { return a.FirstSeen < b.FirstSeen })
+	r.sorted[ByLastSeen] = sortedBy(byMedallion, func(a, b output.MedallionSummary) bool { return a.LastSeen < b.LastSeen })
+	return r
+}
+
+// sortedBy copies summaries ordered by less, ties stay ordered by medallion.
+func sortedBy(byMedallion []output.MedallionSummary, less func(a, b output.MedallionSummary) bool) []output.MedallionSummary {
+	res := make([]output.MedallionSummary, len(byMedallion))
+	copy(res, byMedallion)
+	sort.SliceStable(res, func(i, j int) bool { return less(res[i], res[j]) })
+	return res
+}
+
+// Span returns the first and last pick up dates of all medallions, empty when there are none.
+func (r *Registry) Span() (string, string) {
+	byFirstSeen, byLastSeen := r.sorted[ByFirstSeen], r.sorted[ByLastSeen]
+	if len(byFirstSeen) == 0 {
+		return "", ""
+	}
+	return byFirstSeen[0].FirstSeen, byLastSeen[len(byLastSeen)-1].LastSeen
+}
+
+// Known reports whether medallion was seen in the dataset.
+func (r *Registry) Known(medallion string) bool {
+	byMedallion := r.sorted[ByMedallion]
+	i := sort.Search(len(byMedallion), func(i int) bool { return byMedallion[i].Medallion >= medallion })
+	return i < len(byMedallion) && byMedallion[i].Medallion == medallion
+}
+
+// Medallions lists a page of the medallions starting with the prefix in the sort order.
+func (r *Registry) Medallions(ctx context.Context, opts Options) output.MedallionPage {
+	order := opts.Sort
+	if order == "" {
+		order = ByMedallion
+	}
+	matches := r.sorted[order]
+	if prefix := strings.ToUpper(opts.Prefix); prefix != "" {
+		if order == ByMedallion {
+			matches = withPrefix(matches, prefix)
+		} else {
+			matches = filterPrefix(matches, prefix)
+		}
+	}
+
+	page := output.MedallionPage{Medallions: []output.MedallionSummary{}, Total: len(matches)}
+	if opts.Offset >= len(matches) {
+		return page
+	}
+	end := opts.Offset + opts.Limit
+	if end >= len(matches) {
+		end = len(matches)
+	} else {
+		page.NextOffset = end
+	}
+	for i := opts.Offset; i < end; i++ {
+		idx := i
+		if opts.Desc {
+			idx = len(matches) - 1 - i
+		}
+		page.Medallions = append(page.Medallions, matches[idx])
+	}
+	return page
+}
+
+// withPrefix finds the medallions starting with prefix by binary search on medallions sorted by medallion.
+func withPrefix(byMedallion []output.MedallionSummary, prefix string) []output.MedallionSummary {
+	start := sort.Search(len(byMedallion), func(i int) bool { return byMedallion[i].Medallion >= prefix })
+	end := start
+	for end < len(byMedallion) && strings.HasPrefix(byMedallion[end].Medallion, prefix) {
+		end++
+	}
+	return byMedallion[start:end]
+}
+
+// filterPrefix keeps the order of summaries.
+func filterPrefix(summaries []output.MedallionSummary, prefix string) []output.MedallionSummary {
+	var res []output.MedallionSummary
+	for _, s := range summaries {
+		if strings.HasPrefix(s.Medallion, prefix) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
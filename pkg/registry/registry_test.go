@@ -0,0 +1,149 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/registry"
+)
+
+var (
+	aa = output.MedallionSummary{Medallion: "AA01", Trips: 5, FirstSeen: "2013-12-02", LastSeen: "2013-12-30"}
+	ab = output.MedallionSummary{Medallion: "AB02", Trips: 9, FirstSeen: "2013-12-01", LastSeen: "2013-12-10"}
+	ac = output.MedallionSummary{Medallion: "AC03", Trips: 5, FirstSeen: "2013-12-05", LastSeen: "2013-12-31"}
+	bb = output.MedallionSummary{Medallion: "BB04", Trips: 1, FirstSeen: "2013-12-03", LastSeen: "2013-12-03"}
+)
+
+func TestMedallions(t *testing.T) {
+	reg := registry.New([]output.MedallionSummary{bb, ac, aa, ab})
+	type want struct {
+		Page output.MedallionPage
+	}
+	testTable := []struct {
+		Name string
+		Args registry.Options
+		Want want
+	}{
+		{
+			Name: "All by medallion",
+			Args: registry.Options{Limit: 10},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{aa, ab, ac, bb}, Total: 4}},
+		},
+		{
+			Name: "First page",
+			Args: registry.Options{Sort: registry.ByMedallion, Limit: 3},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{aa, ab, ac}, Total: 4, NextOffset: 3}},
+		},
+		{
+			Name: "Last page",
+			Args: registry.Options{Sort: registry.ByMedallion, Offset: 3, Limit: 3},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{bb}, Total: 4}},
+		},
+		{
+			Name: "Offset past the end",
+			Args: registry.Options{Sort: registry.ByMedallion, Offset: 4, Limit: 3},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{}, Total: 4}},
+		},
+		{
+			Name: "Prefix is case insensitive",
+			Args: registry.Options{Prefix: "a", Sort: registry.ByMedallion, Limit: 10},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{aa, ab, ac}, Total: 3}},
+		},
+		{
+			Name: "Prefix without matches",
+			Args: registry.Options{Prefix: "C", Sort: registry.ByMedallion, Limit: 10},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{}, Total: 0}},
+		},
+		{
+			Name: "By trips, ties by medallion",
+			Args: registry.Options{Sort: registry.ByTrips, Limit: 10},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{bb, aa, ac, ab}, Total: 4}},
+		},
+		{
+			Name: "By trips descending with prefix",
+			Args: registry.Options{Prefix: "A", Sort: registry.ByTrips, Desc: true, Limit: 2},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{ab, ac}, Total: 3, NextOffset: 2}},
+		},
+		{
+			Name: "By first seen",
+			Args: registry.Options{Sort: registry.ByFirstSeen, Limit: 10},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{ab, aa, bb, ac}, Total: 4}},
+		},
+		{
+			Name: "By last seen descending",
+			Args: registry.Options{Sort: registry.ByLastSeen, Desc: true, Offset: 1, Limit: 2},
+			Want: want{Page: output.MedallionPage{Medallions: []output.MedallionSummary{aa, ab}, Total: 4, NextOffset: 3}},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Want.Page, reg.Medallions(context.Background(), tt.Args))
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	testTable := []struct {
+		Name  string
+		Value string
+		Want  registry.Sort
+		Error string
+	}{
+		{Name: "Default", Value: "", Want: registry.ByMedallion},
+		{Name: "Case insensitive", Value: "firstSeen", Want: registry.ByFirstSeen},
+		{Name: "Unknown", Value: "distance", Error: "sort must be one of medallion, trips, firstseen or lastseen"},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			sort, err := registry.ParseSort(tt.Value)
+			if tt.Error != "" {
+				assert.EqualError(t, err, tt.Error)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want, sort)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	reg, err := registry.Load(context.Background(), loader{summaries: []output.MedallionSummary{ab, aa}})
+	require.NoError(t, err)
+	assert.Equal(t, output.MedallionPage{Medallions: []output.MedallionSummary{aa, ab}, Total: 2}, reg.Medallions(context.Background(), registry.Options{Limit: 10}))
+
+	_, err = registry.Load(context.Background(), loader{err: errors.New("sql error")})
+	assert.EqualError(t, err, "failed to load medallions: sql error")
+}
+
+type loader struct {
+	summaries []output.MedallionSummary
+	err       error
+}
+
+func (l loader) MedallionSummaries(ctx context.Context) ([]output.MedallionSummary, error) {
+	return l.summaries, l.err
+}
+
+func TestSpan(t *testing.T) {
+	first, last := registry.New([]output.MedallionSummary{aa, ab, ac, bb}).Span()
+	assert.Equal(t, "2013-12-01", first, "first")
+	assert.Equal(t, "2013-12-31", last, "last")
+
+	first, last = registry.New(nil).Span()
+	assert.Empty(t, first, "first of empty registry")
+	assert.Empty(t, last, "last of empty registry")
+}
+
+func TestKnown(t *testing.T) {
+	reg := registry.New([]output.MedallionSummary{bb, ac, aa, ab})
+	assert.True(t, reg.Known("AA01"), "first medallion")
+	assert.True(t, reg.Known("BB04"), "last medallion")
+	assert.False(t, reg.Known("AB"), "prefix of a medallion")
+	assert.False(t, reg.Known("ZZ99"), "after every medallion")
+	assert.False(t, registry.New(nil).Known("AA01"), "empty registry")
+}
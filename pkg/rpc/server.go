@@ -0,0 +1,218 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/rpc/tripspb"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+// grpcCodes maps the error codes of the REST problems to gRPC codes.
+var grpcCodes = map[string]codes.Code{
+	handler.CodeNotFound:     codes.NotFound,
+	handler.CodeInvalidInput: codes.InvalidArgument,
+	handler.CodeUnavailable:  codes.Unavailable,
+	handler.CodeTimeout:      codes.DeadlineExceeded,
+	handler.CodeCanceled:     codes.Canceled,
+	handler.CodeInternal:     codes.Internal,
+}
+
+// Server serves trip counts over gRPC with the services backing the REST API.
+type Server struct {
+	tripspb.UnimplementedTripsServer
+	logger  *zap.Logger
+	tripSvc handler.Servicer
+	cache   handler.Clearer
+	bounds  validation.Bounds
+}
+
+// NewServer creates the trips server, the zero bounds do not bound dates.
+func NewServer(logger *zap.Logger, tripSvc handler.Servicer, cache handler.Clearer, bounds validation.Bounds) *Server {
+	return &Server{logger: logger, tripSvc: tripSvc, cache: cache, bounds: bounds}
+}
+
+// NewGRPCServer creates a gRPC server serving trips along with health checking and reflection.
+func NewGRPCServer(s *Server, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	tripspb.RegisterTripsServer(srv, s)
+	hs := health.NewServer()
+	hs.SetServingStatus(tripspb.Trips_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	return srv
+}
+
+// CountTripsOnPickUpDate counts the trips of each medallion on a pick up date.
+// The pick up date runs from midnight to midnight in the tz time zone.
+func (s *Server) CountTripsOnPickUpDate(ctx context.Context, req *tripspb.CountTripsOnPickUpDateRequest) (*tripspb.CountTripsResponse, error) {
+	var invalid handler.InvalidParams
+	medallions, err := validation.Medallions(req.GetMedallions(), validation.MaxMedallions)
+	if err != nil {
+		invalid.AddErr("medallions", err)
+	}
+
+	pickupDate, err := validation.Date(req.GetPickupDate())
+	if err != nil {
+		invalid.Add("pickup_date", "invalid pick up date")
+	} else {
+		invalid.Check(s.bounds.Date("pickup_date", pickupDate))
+	}
+
+	zone, err := validation.Zone(req.GetTz())
+	if err != nil {
+		invalid.Add("tz", "invalid tz value")
+	}
+
+	basis, err := toBasis(req.GetBasis())
+	if err != nil {
+		invalid.Add("basis", "invalid basis value")
+	}
+
+	if len(invalid) > 0 {
+		err := invalidArgument(invalid)
+		s.logger.Error("Error: request is not valid", zap.Error(err))
+		return nil, err
+	}
+
+	results, err := s.tripSvc.TripsByMedallionsOnPickUpDate(ctx, medallions, validation.InZone(pickupDate, zone), basis, req.GetBypassCache())
+	if err != nil {
+		s.logger.Error("Error: counting trips", zap.Error(err))
+		return nil, statusOf(err)
+	}
+	return &tripspb.CountTripsResponse{Counts: toCounts(results)}, nil
+}
+
+// CountTrips counts all the trips of each medallion.
+func (s *Server) CountTrips(ctx context.Context, req *tripspb.CountTripsRequest) (*tripspb.CountTripsResponse, error) {
+	var invalid handler.InvalidParams
+	medallions, err := validation.Medallions(req.GetMedallions(), validation.MaxMedallions)
+	if err != nil {
+		invalid.AddErr("medallions", err)
+	}
+
+	if len(invalid) > 0 {
+		err := invalidArgument(invalid)
+		s.logger.Error("Error: request is not valid", zap.Error(err))
+		return nil, err
+	}
+
+	results, err := s.tripSvc.TripsByMedallion(ctx, medallions, req.GetBypassCache())
+	if err != nil {
+		s.logger.Error("Error: counting trips", zap.Error(err))
+		return nil, statusOf(err)
+	}
+	return &tripspb.CountTripsResponse{Counts: toCounts(results)}, nil
+}
+
+// StreamTripCounts counts the trips of a large set of medallions and sends each count as soon as its batch is available.
+// The from and to dates are optional but must be supplied together.
+func (s *Server) StreamTripCounts(req *tripspb.StreamTripCountsRequest, stream grpc.ServerStreamingServer[tripspb.TripCount]) error {
+	var invalid handler.InvalidParams
+	medallions, err := validation.Medallions(req.GetMedallions(), validation.MaxBulkMedallions)
+	if err != nil {
+		invalid.AddErr("medallions", err)
+	}
+
+	from, to, err := validation.Dates(req.GetFrom(), req.GetTo())
+	if err != nil {
+		invalid.AddErr("from", err)
+	} else {
+		invalid.Check(s.bounds.Date("from", from))
+		invalid.Check(s.bounds.Date("to", to))
+	}
+
+	basis, err := toBasis(req.GetBasis())
+	if err != nil {
+		invalid.Add("basis", "invalid basis value")
+	}
+
+	if len(invalid) > 0 {
+		err := invalidArgument(invalid)
+		s.logger.Error("Error: request is not valid", zap.Error(err))
+		return err
+	}
+
+	err = s.tripSvc.StreamTripsByMedallions(stream.Context(), medallions, from, to, basis, req.GetBypassCache(), func(results []output.Result) error {
+		for _, res := range results {
+			if err := stream.Send(toCount(res)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Error: counting trips", zap.Error(err))
+		return statusOf(err)
+	}
+	return nil
+}
+
+// ClearCache flushes the cache entries.
+func (s *Server) ClearCache(ctx context.Context, req *tripspb.ClearCacheRequest) (*tripspb.ClearCacheResponse, error) {
+	evicted := s.cache.Clear(ctx)
+	s.logger.Info("flushed cache entries", zap.Int("evicted", evicted))
+	return &tripspb.ClearCacheResponse{}, nil
+}
+
+// toBasis defaults to pick up when basis is unspecified.
+func toBasis(basis tripspb.Basis) (output.Basis, error) {
+	switch basis {
+	case tripspb.Basis_BASIS_UNSPECIFIED, tripspb.Basis_BASIS_PICKUP:
+		return output.PickUp, nil
+	case tripspb.Basis_BASIS_DROPOFF:
+		return output.DropOff, nil
+	default:
+		return "", failure.Invalid("basis", "unknown basis "+basis.String())
+	}
+}
+
+func toCounts(results []output.Result) []*tripspb.TripCount {
+	counts := make([]*tripspb.TripCount, 0, len(results))
+	for _, res := range results {
+		counts = append(counts, toCount(res))
+	}
+	return counts
+}
+
+func toCount(res output.Result) *tripspb.TripCount {
+	count := &tripspb.TripCount{Medallion: res.Medallion, Trips: int64(res.Trips)}
+	switch res.Status {
+	case output.Known:
+		count.Status = tripspb.Status_STATUS_KNOWN
+	case output.Unknown:
+		count.Status = tripspb.Status_STATUS_UNKNOWN
+	}
+	return count
+}
+
+// invalidArgument is an invalid argument status detailing every invalid field of a request.
+func invalidArgument(invalid handler.InvalidParams) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(invalid))
+	for _, param := range invalid {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: param.Name, Description: param.Reason})
+	}
+	st := status.New(codes.InvalidArgument, "invalid "+strings.Join(invalid.Names(), ", "))
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// statusOf maps err to a gRPC status, unclassified errors are internal errors.
+func statusOf(err error) error {
+	_, code, detail := handler.Classify(err)
+	return status.Error(grpcCodes[code], detail)
+}
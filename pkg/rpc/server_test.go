@@ -0,0 +1,336 @@
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+	"github.com/nikhil-github/api-cab-data/pkg/rpc"
+	"github.com/nikhil-github/api-cab-data/pkg/rpc/tripspb"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+const (
+	med1 = "D7D598CD99978BD012A87A76A7C891B7"
+	med2 = "5455D5FF2BD94D10B304A15D4B7F2735"
+)
+
+// dial serves s in process and returns a connection to it.
+func dial(t *testing.T, s *rpc.Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	srv := rpc.NewGRPCServer(s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Unable to dial bufconn")
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServer_CountTripsOnPickUpDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	bounds, err := validation.NewBounds("2013-12-01", "2013-12-31")
+	require.NoError(t, err)
+	type args struct {
+		Req *tripspb.CountTripsOnPickUpDateRequest
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Counts     []*tripspb.TripCount
+		Code       codes.Code
+		Violations []*errdetails.BadRequest_FieldViolation
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success with normalized medallions in the New York zone",
+			Args: args{Req: &tripspb.CountTripsOnPickUpDateRequest{Medallions: []string{"d7d598cd99978bd012a87a76a7c891b7", med2, med1}, PickupDate: "2013-12-31"}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionsOnPickUpDate", mock.Anything, []string{med1, med2}, time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp, false).
+					Return([]output.Result{{Medallion: med1, Trips: 10, Status: output.Known}, {Medallion: med2, Trips: 0, Status: output.Unknown}}, nil)
+			}},
+			Want: want{Counts: []*tripspb.TripCount{
+				{Medallion: med1, Trips: 10, Status: tripspb.Status_STATUS_KNOWN},
+				{Medallion: med2, Trips: 0, Status: tripspb.Status_STATUS_UNKNOWN},
+			}},
+		},
+		{
+			Name: "Success with tz, drop off basis and by pass cache flag",
+			Args: args{Req: &tripspb.CountTripsOnPickUpDateRequest{Medallions: []string{med1}, PickupDate: "2013-12-31", Tz: "UTC", Basis: tripspb.Basis_BASIS_DROPOFF, BypassCache: true}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionsOnPickUpDate", mock.Anything, []string{med1}, time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC), output.DropOff, true).
+					Return([]output.Result{{Medallion: med1, Trips: 10}}, nil)
+			}},
+			Want: want{Counts: []*tripspb.TripCount{{Medallion: med1, Trips: 10}}},
+		},
+		{
+			Name:   "Failure - Every invalid field is detailed",
+			Args:   args{Req: &tripspb.CountTripsOnPickUpDateRequest{Medallions: []string{"YYYY"}, PickupDate: "2014-01-01", Tz: "Mars/Olympus"}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want: want{Code: codes.InvalidArgument, Violations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "medallions", Description: "not 32 character hex medallions: YYYY"},
+				{Field: "pickup_date", Description: "date must be between 2013-12-01 and 2013-12-31"},
+				{Field: "tz", Description: "invalid tz value"},
+			}},
+		},
+		{
+			Name: "Failure - Query timeout",
+			Args: args{Req: &tripspb.CountTripsOnPickUpDateRequest{Medallions: []string{med1}, PickupDate: "2013-12-31"}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionsOnPickUpDate", mock.Anything, []string{med1}, time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp, false).
+					Return([]output.Result(nil), errors.Wrap(failure.Mark(context.DeadlineExceeded, failure.ErrTimeout), "failed to query"))
+			}},
+			Want: want{Code: codes.DeadlineExceeded},
+		},
+		{
+			Name: "Failure - Unclassified",
+			Args: args{Req: &tripspb.CountTripsOnPickUpDateRequest{Medallions: []string{med1}, PickupDate: "2013-12-31"}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.On("TripsByMedallionsOnPickUpDate", mock.Anything, []string{med1}, time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), output.PickUp, false).
+					Return([]output.Result(nil), errors.New("error"))
+			}},
+			Want: want{Code: codes.Internal},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			client := tripspb.NewTripsClient(dial(t, rpc.NewServer(zap.NewNop(), &m, nil, bounds)))
+			res, err := client.CountTripsOnPickUpDate(context.Background(), tt.Args.Req)
+			m.AssertExpectations(t)
+			assert.Equal(t, tt.Want.Code, status.Code(err), "code")
+			if tt.Want.Code != codes.OK {
+				assertViolations(t, tt.Want.Violations, err)
+				return
+			}
+			assertCounts(t, tt.Want.Counts, res.GetCounts())
+		})
+	}
+}
+
+func TestServer_CountTrips(t *testing.T) {
+	var m mockTripSvc
+	m.On("TripsByMedallion", mock.Anything, []string{med1, med2}, true).
+		Return([]output.Result{{Medallion: med1, Trips: 10, Status: output.Known}, {Medallion: med2, Trips: 3, Status: output.Known}}, nil)
+	m.On("TripsByMedallion", mock.Anything, []string{med2}, false).
+		Return([]output.Result(nil), errors.Wrap(failure.Mark(errors.New("dial tcp: connection refused"), failure.ErrUnavailable), "failed to query"))
+	client := tripspb.NewTripsClient(dial(t, rpc.NewServer(zap.NewNop(), &m, nil, validation.Bounds{})))
+
+	res, err := client.CountTrips(context.Background(), &tripspb.CountTripsRequest{Medallions: []string{med1, med2}, BypassCache: true})
+	require.NoError(t, err)
+	assertCounts(t, []*tripspb.TripCount{
+		{Medallion: med1, Trips: 10, Status: tripspb.Status_STATUS_KNOWN},
+		{Medallion: med2, Trips: 3, Status: tripspb.Status_STATUS_KNOWN},
+	}, res.GetCounts())
+
+	_, err = client.CountTrips(context.Background(), &tripspb.CountTripsRequest{Medallions: []string{med2}})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "database unavailable")
+
+	_, err = client.CountTrips(context.Background(), &tripspb.CountTripsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "missing medallions")
+	assertViolations(t, []*errdetails.BadRequest_FieldViolation{{Field: "medallions", Description: "missing medallions"}}, err)
+	m.AssertExpectations(t)
+}
+
+func TestServer_StreamTripCounts(t *testing.T) {
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Req *tripspb.StreamTripCountsRequest
+	}
+	type fields struct {
+		MockExpectations func(m *mockTripSvc)
+	}
+	type want struct {
+		Counts []*tripspb.TripCount
+		Code   codes.Code
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Success streams each batch",
+			Args: args{Req: &tripspb.StreamTripCountsRequest{Medallions: []string{med1, med2}, From: "2013-12-01", To: "2013-12-31"}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnStream([]string{med1, med2}, from, to, output.PickUp, false, []output.Result{{Medallion: med1, Trips: 10}}, []output.Result{{Medallion: med2, Trips: 3}}).Return(nil)
+			}},
+			Want: want{Counts: []*tripspb.TripCount{{Medallion: med1, Trips: 10}, {Medallion: med2, Trips: 3}}},
+		},
+		{
+			Name:   "Failure - Only from date",
+			Args:   args{Req: &tripspb.StreamTripCountsRequest{Medallions: []string{med1}, From: "2013-12-01"}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {}},
+			Want:   want{Code: codes.InvalidArgument},
+		},
+		{
+			Name: "Failure while streaming",
+			Args: args{Req: &tripspb.StreamTripCountsRequest{Medallions: []string{med1, med2}}},
+			Fields: fields{MockExpectations: func(m *mockTripSvc) {
+				m.OnStream([]string{med1, med2}, time.Time{}, time.Time{}, output.PickUp, false, []output.Result{{Medallion: med1, Trips: 10}}).Return(errors.New("error"))
+			}},
+			Want: want{Counts: []*tripspb.TripCount{{Medallion: med1, Trips: 10}}, Code: codes.Internal},
+		},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var m mockTripSvc
+			tt.Fields.MockExpectations(&m)
+			client := tripspb.NewTripsClient(dial(t, rpc.NewServer(zap.NewNop(), &m, nil, validation.Bounds{})))
+			stream, err := client.StreamTripCounts(context.Background(), tt.Args.Req)
+			require.NoError(t, err)
+			var counts []*tripspb.TripCount
+			for {
+				count, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					assert.Equal(t, tt.Want.Code, status.Code(err), "code")
+					break
+				}
+				counts = append(counts, count)
+			}
+			m.AssertExpectations(t)
+			assertCounts(t, tt.Want.Counts, counts)
+		})
+	}
+}
+
+func TestServer_ClearCache(t *testing.T) {
+	var c mockClearer
+	c.On("Clear", mock.Anything).Return(3)
+	client := tripspb.NewTripsClient(dial(t, rpc.NewServer(zap.NewNop(), nil, &c, validation.Bounds{})))
+	_, err := client.ClearCache(context.Background(), &tripspb.ClearCacheRequest{})
+	assert.NoError(t, err)
+	c.AssertExpectations(t)
+}
+
+func TestServer_HealthAndReflection(t *testing.T) {
+	conn := dial(t, rpc.NewServer(zap.NewNop(), nil, nil, validation.Bounds{}))
+
+	res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "cabdata.trips.v1.Trips"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.GetStatus(), "trips health")
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}}))
+	info, err := stream.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, s := range info.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	assert.Contains(t, services, "cabdata.trips.v1.Trips", "reflected services")
+	assert.Contains(t, services, "grpc.health.v1.Health", "reflected services")
+}
+
+func assertCounts(t *testing.T, want, got []*tripspb.TripCount) {
+	require.Len(t, got, len(want), "counts")
+	for i := range want {
+		assert.Equal(t, want[i].GetMedallion(), got[i].GetMedallion(), "medallion")
+		assert.Equal(t, want[i].GetTrips(), got[i].GetTrips(), "trips")
+		assert.Equal(t, want[i].GetStatus(), got[i].GetStatus(), "status")
+	}
+}
+
+func assertViolations(t *testing.T, want []*errdetails.BadRequest_FieldViolation, err error) {
+	var got []*errdetails.BadRequest_FieldViolation
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			got = append(got, badRequest.GetFieldViolations()...)
+		}
+	}
+	require.Len(t, got, len(want), "violations")
+	for i := range want {
+		assert.Equal(t, want[i].GetField(), got[i].GetField(), "field")
+		assert.Equal(t, want[i].GetDescription(), got[i].GetDescription(), "description")
+	}
+}
+
+// mockTripSvc mocks the methods of handler.Servicer served over gRPC.
+type mockTripSvc struct {
+	mock.Mock
+	handler.Servicer
+}
+
+func (m *mockTripSvc) TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, pickUpDate, basis, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) TripsByMedallion(ctx context.Context, medallions []string, byPassCache bool) ([]output.Result, error) {
+	args := m.Called(ctx, medallions, byPassCache)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (m *mockTripSvc) StreamTripsByMedallions(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool, fn func([]output.Result) error) error {
+	args := m.Called(ctx, medallions, from, to, basis, byPassCache)
+	for _, chunk := range args.Get(1).([][]output.Result) {
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	return args.Error(0)
+}
+
+// OnStream expects a stream that hands chunks to the callback before returning.
+func (m *mockTripSvc) OnStream(medallions []string, from, to time.Time, basis output.Basis, byPassCache bool, chunks ...[]output.Result) *streamCall {
+	return &streamCall{call: m.On("StreamTripsByMedallions", mock.Anything, medallions, from, to, basis, byPassCache), chunks: chunks}
+}
+
+type streamCall struct {
+	call   *mock.Call
+	chunks [][]output.Result
+}
+
+func (c *streamCall) Return(err error) *mock.Call {
+	return c.call.Return(err, c.chunks)
+}
+
+type mockClearer struct {
+	mock.Mock
+}
+
+func (m *mockClearer) Clear(ctx context.Context) int {
+	args := m.Called(ctx)
+	return args.Int(0)
+}
+
+func (m *mockClearer) Invalidate(ctx context.Context, sel cache.Selector) int {
+	args := m.Called(ctx, sel)
+	return args.Int(0)
+}
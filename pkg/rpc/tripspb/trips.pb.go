@@ -0,0 +1,768 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: pkg/rpc/tripspb/trips.proto
+
+package tripspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Basis selects whether trips are counted by their pick up or their drop off.
+type Basis int32
+
+const (
+	Basis_BASIS_UNSPECIFIED Basis = 0
+	Basis_BASIS_PICKUP      Basis = 1
+	Basis_BASIS_DROPOFF     Basis = 2
+)
+
+// Enum value maps for Basis.
+var (
+	Basis_name = map[int32]string{
+		0: "BASIS_UNSPECIFIED",
+		1: "BASIS_PICKUP",
+		2: "BASIS_DROPOFF",
+	}
+	Basis_value = map[string]int32{
+		"BASIS_UNSPECIFIED": 0,
+		"BASIS_PICKUP":      1,
+		"BASIS_DROPOFF":     2,
+	}
+)
+
+func (x Basis) Enum() *Basis {
+	p := new(Basis)
+	*p = x
+	return p
+}
+
+func (x Basis) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Basis) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_rpc_tripspb_trips_proto_enumTypes[0].Descriptor()
+}
+
+func (Basis) Type() protoreflect.EnumType {
+	return &file_pkg_rpc_tripspb_trips_proto_enumTypes[0]
+}
+
+func (x Basis) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Basis.Descriptor instead.
+func (Basis) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{0}
+}
+
+// Status tells medallions without trips apart from medallions never seen.
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_KNOWN       Status = 1
+	Status_STATUS_UNKNOWN     Status = 2
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_KNOWN",
+		2: "STATUS_UNKNOWN",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_KNOWN":       1,
+		"STATUS_UNKNOWN":     2,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_rpc_tripspb_trips_proto_enumTypes[1].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_pkg_rpc_tripspb_trips_proto_enumTypes[1]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{1}
+}
+
+type CountTripsOnPickUpDateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medallions []string `protobuf:"bytes,1,rep,name=medallions,proto3" json:"medallions,omitempty"`
+	PickupDate string   `protobuf:"bytes,2,opt,name=pickup_date,json=pickupDate,proto3" json:"pickup_date,omitempty"`
+	// tz is the time zone of the pick up date, America/New_York when empty.
+	Tz          string `protobuf:"bytes,3,opt,name=tz,proto3" json:"tz,omitempty"`
+	Basis       Basis  `protobuf:"varint,4,opt,name=basis,proto3,enum=cabdata.trips.v1.Basis" json:"basis,omitempty"`
+	BypassCache bool   `protobuf:"varint,5,opt,name=bypass_cache,json=bypassCache,proto3" json:"bypass_cache,omitempty"`
+}
+
+func (x *CountTripsOnPickUpDateRequest) Reset() {
+	*x = CountTripsOnPickUpDateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountTripsOnPickUpDateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountTripsOnPickUpDateRequest) ProtoMessage() {}
+
+func (x *CountTripsOnPickUpDateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountTripsOnPickUpDateRequest.ProtoReflect.Descriptor instead.
+func (*CountTripsOnPickUpDateRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CountTripsOnPickUpDateRequest) GetMedallions() []string {
+	if x != nil {
+		return x.Medallions
+	}
+	return nil
+}
+
+func (x *CountTripsOnPickUpDateRequest) GetPickupDate() string {
+	if x != nil {
+		return x.PickupDate
+	}
+	return ""
+}
+
+func (x *CountTripsOnPickUpDateRequest) GetTz() string {
+	if x != nil {
+		return x.Tz
+	}
+	return ""
+}
+
+func (x *CountTripsOnPickUpDateRequest) GetBasis() Basis {
+	if x != nil {
+		return x.Basis
+	}
+	return Basis_BASIS_UNSPECIFIED
+}
+
+func (x *CountTripsOnPickUpDateRequest) GetBypassCache() bool {
+	if x != nil {
+		return x.BypassCache
+	}
+	return false
+}
+
+type CountTripsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medallions  []string `protobuf:"bytes,1,rep,name=medallions,proto3" json:"medallions,omitempty"`
+	BypassCache bool     `protobuf:"varint,2,opt,name=bypass_cache,json=bypassCache,proto3" json:"bypass_cache,omitempty"`
+}
+
+func (x *CountTripsRequest) Reset() {
+	*x = CountTripsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountTripsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountTripsRequest) ProtoMessage() {}
+
+func (x *CountTripsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountTripsRequest.ProtoReflect.Descriptor instead.
+func (*CountTripsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CountTripsRequest) GetMedallions() []string {
+	if x != nil {
+		return x.Medallions
+	}
+	return nil
+}
+
+func (x *CountTripsRequest) GetBypassCache() bool {
+	if x != nil {
+		return x.BypassCache
+	}
+	return false
+}
+
+type StreamTripCountsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medallions []string `protobuf:"bytes,1,rep,name=medallions,proto3" json:"medallions,omitempty"`
+	// from and to are optional but must be supplied together.
+	From        string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To          string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Basis       Basis  `protobuf:"varint,4,opt,name=basis,proto3,enum=cabdata.trips.v1.Basis" json:"basis,omitempty"`
+	BypassCache bool   `protobuf:"varint,5,opt,name=bypass_cache,json=bypassCache,proto3" json:"bypass_cache,omitempty"`
+}
+
+func (x *StreamTripCountsRequest) Reset() {
+	*x = StreamTripCountsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamTripCountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTripCountsRequest) ProtoMessage() {}
+
+func (x *StreamTripCountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTripCountsRequest.ProtoReflect.Descriptor instead.
+func (*StreamTripCountsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamTripCountsRequest) GetMedallions() []string {
+	if x != nil {
+		return x.Medallions
+	}
+	return nil
+}
+
+func (x *StreamTripCountsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *StreamTripCountsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *StreamTripCountsRequest) GetBasis() Basis {
+	if x != nil {
+		return x.Basis
+	}
+	return Basis_BASIS_UNSPECIFIED
+}
+
+func (x *StreamTripCountsRequest) GetBypassCache() bool {
+	if x != nil {
+		return x.BypassCache
+	}
+	return false
+}
+
+type TripCount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medallion string `protobuf:"bytes,1,opt,name=medallion,proto3" json:"medallion,omitempty"`
+	Trips     int64  `protobuf:"varint,2,opt,name=trips,proto3" json:"trips,omitempty"`
+	Status    Status `protobuf:"varint,3,opt,name=status,proto3,enum=cabdata.trips.v1.Status" json:"status,omitempty"`
+}
+
+func (x *TripCount) Reset() {
+	*x = TripCount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TripCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TripCount) ProtoMessage() {}
+
+func (x *TripCount) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TripCount.ProtoReflect.Descriptor instead.
+func (*TripCount) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TripCount) GetMedallion() string {
+	if x != nil {
+		return x.Medallion
+	}
+	return ""
+}
+
+func (x *TripCount) GetTrips() int64 {
+	if x != nil {
+		return x.Trips
+	}
+	return 0
+}
+
+func (x *TripCount) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+type CountTripsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Counts []*TripCount `protobuf:"bytes,1,rep,name=counts,proto3" json:"counts,omitempty"`
+}
+
+func (x *CountTripsResponse) Reset() {
+	*x = CountTripsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountTripsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountTripsResponse) ProtoMessage() {}
+
+func (x *CountTripsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountTripsResponse.ProtoReflect.Descriptor instead.
+func (*CountTripsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CountTripsResponse) GetCounts() []*TripCount {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+type ClearCacheRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ClearCacheRequest) Reset() {
+	*x = ClearCacheRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClearCacheRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearCacheRequest) ProtoMessage() {}
+
+func (x *ClearCacheRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearCacheRequest.ProtoReflect.Descriptor instead.
+func (*ClearCacheRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{5}
+}
+
+type ClearCacheResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ClearCacheResponse) Reset() {
+	*x = ClearCacheResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClearCacheResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearCacheResponse) ProtoMessage() {}
+
+func (x *ClearCacheResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_tripspb_trips_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearCacheResponse.ProtoReflect.Descriptor instead.
+func (*ClearCacheResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_tripspb_trips_proto_rawDescGZIP(), []int{6}
+}
+
+var File_pkg_rpc_tripspb_trips_proto protoreflect.FileDescriptor
+
+var file_pkg_rpc_tripspb_trips_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x70, 0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63, 0x2f, 0x74, 0x72, 0x69, 0x70, 0x73, 0x70,
+	0x62, 0x2f, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x10, 0x63,
+	0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x22,
+	0xc2, 0x01, 0x0a, 0x1d, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x4f, 0x6e,
+	0x50, 0x69, 0x63, 0x6b, 0x55, 0x70, 0x44, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x65, 0x64, 0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x65, 0x64, 0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x69, 0x63, 0x6b, 0x75, 0x70, 0x5f, 0x64, 0x61, 0x74, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x69, 0x63, 0x6b, 0x75, 0x70, 0x44, 0x61,
+	0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x7a, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x74, 0x7a, 0x12, 0x2d, 0x0a, 0x05, 0x62, 0x61, 0x73, 0x69, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x17, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x73, 0x69, 0x73, 0x52, 0x05, 0x62, 0x61, 0x73, 0x69,
+	0x73, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x79, 0x70, 0x61, 0x73, 0x73, 0x5f, 0x63, 0x61, 0x63, 0x68,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x62, 0x79, 0x70, 0x61, 0x73, 0x73, 0x43,
+	0x61, 0x63, 0x68, 0x65, 0x22, 0x56, 0x0a, 0x11, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69,
+	0x70, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x65, 0x64,
+	0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x6d,
+	0x65, 0x64, 0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x79, 0x70,
+	0x61, 0x73, 0x73, 0x5f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x62, 0x79, 0x70, 0x61, 0x73, 0x73, 0x43, 0x61, 0x63, 0x68, 0x65, 0x22, 0xaf, 0x01, 0x0a,
+	0x17, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x69, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x65, 0x64, 0x61,
+	0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x65,
+	0x64, 0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02,
+	0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x2d, 0x0a, 0x05,
+	0x62, 0x61, 0x73, 0x69, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x63, 0x61,
+	0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x61, 0x73, 0x69, 0x73, 0x52, 0x05, 0x62, 0x61, 0x73, 0x69, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x62,
+	0x79, 0x70, 0x61, 0x73, 0x73, 0x5f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0b, 0x62, 0x79, 0x70, 0x61, 0x73, 0x73, 0x43, 0x61, 0x63, 0x68, 0x65, 0x22, 0x71,
+	0x0a, 0x09, 0x54, 0x72, 0x69, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x6d,
+	0x65, 0x64, 0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x6d, 0x65, 0x64, 0x61, 0x6c, 0x6c, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x72, 0x69,
+	0x70, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x72, 0x69, 0x70, 0x73, 0x12,
+	0x30, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x18, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x22, 0x49, 0x0a, 0x12, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61, 0x74,
+	0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x69, 0x70, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x22, 0x13, 0x0a, 0x11,
+	0x43, 0x6c, 0x65, 0x61, 0x72, 0x43, 0x61, 0x63, 0x68, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x22, 0x14, 0x0a, 0x12, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x43, 0x61, 0x63, 0x68, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a, 0x43, 0x0a, 0x05, 0x42, 0x61, 0x73, 0x69, 0x73,
+	0x12, 0x15, 0x0a, 0x11, 0x42, 0x41, 0x53, 0x49, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x42, 0x41, 0x53, 0x49, 0x53,
+	0x5f, 0x50, 0x49, 0x43, 0x4b, 0x55, 0x50, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x42, 0x41, 0x53,
+	0x49, 0x53, 0x5f, 0x44, 0x52, 0x4f, 0x50, 0x4f, 0x46, 0x46, 0x10, 0x02, 0x2a, 0x46, 0x0a, 0x06,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10,
+	0x0a, 0x0c, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x01,
+	0x12, 0x12, 0x0a, 0x0e, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f,
+	0x57, 0x4e, 0x10, 0x02, 0x32, 0x88, 0x03, 0x0a, 0x05, 0x54, 0x72, 0x69, 0x70, 0x73, 0x12, 0x6f,
+	0x0a, 0x16, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x4f, 0x6e, 0x50, 0x69,
+	0x63, 0x6b, 0x55, 0x70, 0x44, 0x61, 0x74, 0x65, 0x12, 0x2f, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61,
+	0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x4f, 0x6e, 0x50, 0x69, 0x63, 0x6b, 0x55, 0x70, 0x44, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x63, 0x61, 0x62, 0x64,
+	0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x57, 0x0a, 0x0a, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x12, 0x23, 0x2e,
+	0x63, 0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x24, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69,
+	0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x72, 0x69, 0x70, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5c, 0x0a, 0x10, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x54, 0x72, 0x69, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x12, 0x29, 0x2e, 0x63,
+	0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x69, 0x70, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61, 0x74,
+	0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x69, 0x70, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x30, 0x01, 0x12, 0x57, 0x0a, 0x0a, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x43,
+	0x61, 0x63, 0x68, 0x65, 0x12, 0x23, 0x2e, 0x63, 0x61, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x74,
+	0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x43, 0x61, 0x63,
+	0x68, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x63, 0x61, 0x62, 0x64,
+	0x61, 0x74, 0x61, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x65,
+	0x61, 0x72, 0x43, 0x61, 0x63, 0x68, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
+	0x37, 0x5a, 0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6e, 0x69,
+	0x6b, 0x68, 0x69, 0x6c, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2f, 0x61, 0x70, 0x69, 0x2d,
+	0x63, 0x61, 0x62, 0x2d, 0x64, 0x61, 0x74, 0x61, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63,
+	0x2f, 0x74, 0x72, 0x69, 0x70, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_rpc_tripspb_trips_proto_rawDescOnce sync.Once
+	file_pkg_rpc_tripspb_trips_proto_rawDescData = file_pkg_rpc_tripspb_trips_proto_rawDesc
+)
+
+func file_pkg_rpc_tripspb_trips_proto_rawDescGZIP() []byte {
+	file_pkg_rpc_tripspb_trips_proto_rawDescOnce.Do(func() {
+		file_pkg_rpc_tripspb_trips_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_rpc_tripspb_trips_proto_rawDescData)
+	})
+	return file_pkg_rpc_tripspb_trips_proto_rawDescData
+}
+
+var file_pkg_rpc_tripspb_trips_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_pkg_rpc_tripspb_trips_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_pkg_rpc_tripspb_trips_proto_goTypes = []any{
+	(Basis)(0),                            // 0: cabdata.trips.v1.Basis
+	(Status)(0),                           // 1: cabdata.trips.v1.Status
+	(*CountTripsOnPickUpDateRequest)(nil), // 2: cabdata.trips.v1.CountTripsOnPickUpDateRequest
+	(*CountTripsRequest)(nil),             // 3: cabdata.trips.v1.CountTripsRequest
+	(*StreamTripCountsRequest)(nil),       // 4: cabdata.trips.v1.StreamTripCountsRequest
+	(*TripCount)(nil),                     // 5: cabdata.trips.v1.TripCount
+	(*CountTripsResponse)(nil),            // 6: cabdata.trips.v1.CountTripsResponse
+	(*ClearCacheRequest)(nil),             // 7: cabdata.trips.v1.ClearCacheRequest
+	(*ClearCacheResponse)(nil),            // 8: cabdata.trips.v1.ClearCacheResponse
+}
+var file_pkg_rpc_tripspb_trips_proto_depIdxs = []int32{
+	0, // 0: cabdata.trips.v1.CountTripsOnPickUpDateRequest.basis:type_name -> cabdata.trips.v1.Basis
+	0, // 1: cabdata.trips.v1.StreamTripCountsRequest.basis:type_name -> cabdata.trips.v1.Basis
+	1, // 2: cabdata.trips.v1.TripCount.status:type_name -> cabdata.trips.v1.Status
+	5, // 3: cabdata.trips.v1.CountTripsResponse.counts:type_name -> cabdata.trips.v1.TripCount
+	2, // 4: cabdata.trips.v1.Trips.CountTripsOnPickUpDate:input_type -> cabdata.trips.v1.CountTripsOnPickUpDateRequest
+	3, // 5: cabdata.trips.v1.Trips.CountTrips:input_type -> cabdata.trips.v1.CountTripsRequest
+	4, // 6: cabdata.trips.v1.Trips.StreamTripCounts:input_type -> cabdata.trips.v1.StreamTripCountsRequest
+	7, // 7: cabdata.trips.v1.Trips.ClearCache:input_type -> cabdata.trips.v1.ClearCacheRequest
+	6, // 8: cabdata.trips.v1.Trips.CountTripsOnPickUpDate:output_type -> cabdata.trips.v1.CountTripsResponse
+	6, // 9: cabdata.trips.v1.Trips.CountTrips:output_type -> cabdata.trips.v1.CountTripsResponse
+	5, // 10: cabdata.trips.v1.Trips.StreamTripCounts:output_type -> cabdata.trips.v1.TripCount
+	8, // 11: cabdata.trips.v1.Trips.ClearCache:output_type -> cabdata.trips.v1.ClearCacheResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_pkg_rpc_tripspb_trips_proto_init() }
+func file_pkg_rpc_tripspb_trips_proto_init() {
+	if File_pkg_rpc_tripspb_trips_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*CountTripsOnPickUpDateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*CountTripsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamTripCountsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*TripCount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*CountTripsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ClearCacheRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_tripspb_trips_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ClearCacheResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_rpc_tripspb_trips_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_rpc_tripspb_trips_proto_goTypes,
+		DependencyIndexes: file_pkg_rpc_tripspb_trips_proto_depIdxs,
+		EnumInfos:         file_pkg_rpc_tripspb_trips_proto_enumTypes,
+		MessageInfos:      file_pkg_rpc_tripspb_trips_proto_msgTypes,
+	}.Build()
+	File_pkg_rpc_tripspb_trips_proto = out.File
+	file_pkg_rpc_tripspb_trips_proto_rawDesc = nil
+	file_pkg_rpc_tripspb_trips_proto_goTypes = nil
+	file_pkg_rpc_tripspb_trips_proto_depIdxs = nil
+}
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pkg/rpc/tripspb/trips.proto
+
+package tripspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Trips_CountTripsOnPickUpDate_FullMethodName = "/cabdata.trips.v1.Trips/CountTripsOnPickUpDate"
+	Trips_CountTrips_FullMethodName             = "/cabdata.trips.v1.Trips/CountTrips"
+	Trips_StreamTripCounts_FullMethodName       = "/cabdata.trips.v1.Trips/StreamTripCounts"
+	Trips_ClearCache_FullMethodName             = "/cabdata.trips.v1.Trips/ClearCache"
+)
+
+// TripsClient is the client API for Trips service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Trips counts cab trips by medallion.
+// Dates are formatted as YYYY-MM-DD and medallions are 32 character hex MD5 hashes.
+type TripsClient interface {
+	// CountTripsOnPickUpDate counts the trips of each medallion on a pick up date.
+	CountTripsOnPickUpDate(ctx context.Context, in *CountTripsOnPickUpDateRequest, opts ...grpc.CallOption) (*CountTripsResponse, error)
+	// CountTrips counts all the trips of each medallion.
+	CountTrips(ctx context.Context, in *CountTripsRequest, opts ...grpc.CallOption) (*CountTripsResponse, error)
+	// StreamTripCounts counts the trips of a large set of medallions, streaming each count as its batch completes.
+	StreamTripCounts(ctx context.Context, in *StreamTripCountsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TripCount], error)
+	// ClearCache flushes the cache entries.
+	ClearCache(ctx context.Context, in *ClearCacheRequest, opts ...grpc.CallOption) (*ClearCacheResponse, error)
+}
+
+type tripsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTripsClient(cc grpc.ClientConnInterface) TripsClient {
+	return &tripsClient{cc}
+}
+
+func (c *tripsClient) CountTripsOnPickUpDate(ctx context.Context, in *CountTripsOnPickUpDateRequest, opts ...grpc.CallOption) (*CountTripsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountTripsResponse)
+	err := c.cc.Invoke(ctx, Trips_CountTripsOnPickUpDate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tripsClient) CountTrips(ctx context.Context, in *CountTripsRequest, opts ...grpc.CallOption) (*CountTripsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountTripsResponse)
+	err := c.cc.Invoke(ctx, Trips_CountTrips_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tripsClient) StreamTripCounts(ctx context.Context, in *StreamTripCountsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TripCount], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Trips_ServiceDesc.Streams[0], Trips_StreamTripCounts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTripCountsRequest, TripCount]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Trips_StreamTripCountsClient = grpc.ServerStreamingClient[TripCount]
+
+func (c *tripsClient) ClearCache(ctx context.Context, in *ClearCacheRequest, opts ...grpc.CallOption) (*ClearCacheResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClearCacheResponse)
+	err := c.cc.Invoke(ctx, Trips_ClearCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TripsServer is the server API for Trips service.
+// All implementations must embed UnimplementedTripsServer
+// for forward compatibility.
+//
+// Trips counts cab trips by medallion.
+// Dates are formatted as YYYY-MM-DD and medallions are 32 character hex MD5 hashes.
+type TripsServer interface {
+	// CountTripsOnPickUpDate counts the trips of each medallion on a pick up date.
+	CountTripsOnPickUpDate(context.Context, *CountTripsOnPickUpDateRequest) (*CountTripsResponse, error)
+	// CountTrips counts all the trips of each medallion.
+	CountTrips(context.Context, *CountTripsRequest) (*CountTripsResponse, error)
+	// StreamTripCounts counts the trips of a large set of medallions, streaming each count as its batch completes.
+	StreamTripCounts(*StreamTripCountsRequest, grpc.ServerStreamingServer[TripCount]) error
+	// ClearCache flushes the cache entries.
+	ClearCache(context.Context, *ClearCacheRequest) (*ClearCacheResponse, error)
+	mustEmbedUnimplementedTripsServer()
+}
+
+// UnimplementedTripsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTripsServer struct{}
+
+func (UnimplementedTripsServer) CountTripsOnPickUpDate(context.Context, *CountTripsOnPickUpDateRequest) (*CountTripsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountTripsOnPickUpDate not implemented")
+}
+func (UnimplementedTripsServer) CountTrips(context.Context, *CountTripsRequest) (*CountTripsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountTrips not implemented")
+}
+func (UnimplementedTripsServer) StreamTripCounts(*StreamTripCountsRequest, grpc.ServerStreamingServer[TripCount]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTripCounts not implemented")
+}
+func (UnimplementedTripsServer) ClearCache(context.Context, *ClearCacheRequest) (*ClearCacheResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearCache not implemented")
+}
+func (UnimplementedTripsServer) mustEmbedUnimplementedTripsServer() {}
+func (UnimplementedTripsServer) testEmbeddedByValue()               {}
+
+// UnsafeTripsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TripsServer will
+// result in compilation errors.
+type UnsafeTripsServer interface {
+	mustEmbedUnimplementedTripsServer()
+}
+
+func RegisterTripsServer(s grpc.ServiceRegistrar, srv TripsServer) {
+	// If the following call pancis, it indicates UnimplementedTripsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Trips_ServiceDesc, srv)
+}
+
+func _Trips_CountTripsOnPickUpDate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountTripsOnPickUpDateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TripsServer).CountTripsOnPickUpDate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Trips_CountTripsOnPickUpDate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TripsServer).CountTripsOnPickUpDate(ctx, req.(*CountTripsOnPickUpDateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Trips_CountTrips_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountTripsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TripsServer).CountTrips(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Trips_CountTrips_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TripsServer).CountTrips(ctx, req.(*CountTripsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Trips_StreamTripCounts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTripCountsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TripsServer).StreamTripCounts(m, &grpc.GenericServerStream[StreamTripCountsRequest, TripCount]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Trips_StreamTripCountsServer = grpc.ServerStreamingServer[TripCount]
+
+func _Trips_ClearCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearCacheRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TripsServer).ClearCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Trips_ClearCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TripsServer).ClearCache(ctx, req.(*ClearCacheRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Trips_ServiceDesc is the grpc.ServiceDesc for Trips service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Trips_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cabdata.trips.v1.Trips",
+	HandlerType: (*TripsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CountTripsOnPickUpDate",
+			Handler:    _Trips_CountTripsOnPickUpDate_Handler,
+		},
+		{
+			MethodName: "CountTrips",
+			Handler:    _Trips_CountTrips_Handler,
+		},
+		{
+			MethodName: "ClearCache",
+			Handler:    _Trips_ClearCache_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTripCounts",
+			Handler:       _Trips_StreamTripCounts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/rpc/tripspb/trips.proto",
+}
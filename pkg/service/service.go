@@ -2,138 +2,608 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"time"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
 	"github.com/nikhil-github/api-cab-data/pkg/output"
 )
 
-const keyNotFound = "Key not found in cache"
+const dateLayout = "2006-01-02"
 
 // TripService embeds dependencies for counting trips.
 type TripService struct {
 	cacheGetter CacheGetter
 	cacheSetter CacheSetter
 	dbGetter    Getter
+	zone        *time.Location
+	registry    Registry
 	logger      *zap.Logger
 }
 
 // Getter provides method to get trip count from DB.
 type Getter interface {
-	TripsByMedallionsOnPickUpDate(ctx context.Context, medallion string, pickUpDate time.Time) (output.Result, error)
+	TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis) ([]output.Result, error)
 	TripsByMedallion(ctx context.Context, medallions []string) ([]output.Result, error)
+	TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) ([]output.Result, error)
+	TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis) ([]output.DailyResult, error)
+	PickUpHistogram(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (output.Histogram, error)
+	TripsByMedallionsInChunks(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, fn func([]string, []output.Result) error) error
+	Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) ([]output.Trip, error)
+	TopMedallions(ctx context.Context, from, to time.Time, basis output.Basis, limit int) ([]output.Result, error)
+	TripsByDriver(ctx context.Context, licenses []string) ([]output.DriverResult, error)
+	TripsByDriverOnPickUpDate(ctx context.Context, license string, pickUpDate time.Time, basis output.Basis) (output.DriverResult, error)
+	TripsInArea(ctx context.Context, area geo.Area, from, to time.Time, basis output.Basis, medallions []string) ([]output.Result, error)
+	Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) ([]output.Metrics, error)
+}
+
+// Registry tells the medallions seen in the dataset from unknown medallions.
+type Registry interface {
+	Known(medallion string) bool
 }
 
 // CacheGetter provides method to get trip count from Cache.
 type CacheGetter interface {
 	Get(ctx context.Context, key string) (int, error)
+	GetRanking(ctx context.Context, key string) ([]output.RankedResult, error)
+	GetDriver(ctx context.Context, key string) (output.DriverResult, error)
+	GetMetrics(ctx context.Context, key string) (output.Metrics, error)
 }
 
 // CacheSetter provides method to cache trip counts.
+// Trip counts are cached under the category setting their time to live.
 type CacheSetter interface {
-	Set(ctx context.Context, key string, val int)
+	Set(ctx context.Context, category cache.Category, key string, val int)
+	SetRanking(ctx context.Context, key string, val []output.RankedResult)
+	SetDriver(ctx context.Context, key string, val output.DriverResult)
+	SetMetrics(ctx context.Context, key string, val output.Metrics)
 }
 
 // New creates a new Tripservice.
-func New(g Getter, cg CacheGetter, cs CacheSetter, l *zap.Logger) *TripService {
-	return &TripService{dbGetter: g, cacheGetter: cg, cacheSetter: cs, logger: l}
+// zone is the time zone the trip date times of g were recorded in, the time zone of the dates of daily counts.
+func New(g Getter, zone *time.Location, r Registry, cg CacheGetter, cs CacheSetter, l *zap.Logger) *TripService {
+	return &TripService{dbGetter: g, zone: zone, registry: r, cacheGetter: cg, cacheSetter: cs, logger: l}
 }
 
-// TripsByMedallionsOnPickUpDate get the number of trips for each medallion by pickup date.
+// TripsByMedallionsOnPickUpDate get the number of trips for each medallion by pickup or dropoff date per basis.
+// The pick up date is the midnight the day starts at in its time zone.
 // Check cache entries first before finding in DB.
+// By pass cache with byPassCache flag equals true.
+// Cache key is cache.DateKey of the medallion, date, time zone and basis.
 // Query DB for cache misses.
-func (s *TripService) TripsByMedallionsOnPickUpDate(ctx context.Context, medallion string, pickUpDate time.Time, byPassCache bool) (output.Result, error) {
-	var err error
-	var result output.Result
+// Results follow the order of medallions and medallions without trips are reported with zero trips and their status.
+func (s *TripService) TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	counts := make(map[string]int, len(medallions))
+	var dbMedallions []string
 	if byPassCache {
-		result, err = s.getFromDBByPickUpDate(ctx, medallion, pickUpDate)
-		if err != nil {
-			s.logger.Error("Error finding trips", zap.String("medallion", medallion), zap.Time("pickupdate", pickUpDate))
-			return output.Result{}, err
-		}
+		dbMedallions = medallions
 	} else {
-		var tripCount int
-		tripCount, err = s.cacheGetter.Get(ctx, key(medallion, pickUpDate))
-		if err != nil && err.Error() == keyNotFound {
-			result, err = s.getFromDBByPickUpDate(ctx, medallion, pickUpDate)
-			if err != nil {
-				s.logger.Error("Error finding trips", zap.String("medallion", medallion), zap.Time("pickupdate", pickUpDate))
-				return output.Result{}, err
+		for _, med := range medallions {
+			tripCount, err := s.cacheGetter.Get(ctx, cache.DateKey(med, pickUpDate, basis))
+			if failure.Is(err, failure.ErrCacheMiss) {
+				dbMedallions = append(dbMedallions, med)
+			} else {
+				counts[med] = tripCount
 			}
-		} else {
-			result = output.Result{Medallion: medallion, Trips: tripCount}
 		}
 	}
-	return result, nil
+	if len(dbMedallions) > 0 {
+		dbCounts, err := s.getFromDBByPickUpDate(ctx, dbMedallions, pickUpDate, basis)
+		if err != nil {
+			s.logger.Error("Error finding trips", zap.Strings("medallions", medallions), zap.Time("pickupdate", pickUpDate))
+			return []output.Result{}, err
+		}
+		for med, tripCount := range dbCounts {
+			counts[med] = tripCount
+		}
+	}
+	return s.inOrder(medallions, counts), nil
+}
+
+// inOrder lists the trips of medallions in order with their status.
+// Medallions without trips are checked in the registry to tell known from unknown medallions.
+func (s *TripService) inOrder(medallions []string, counts map[string]int) []output.Result {
+	results := make([]output.Result, 0, len(medallions))
+	for _, med := range medallions {
+		status := output.Known
+		if counts[med] == 0 && !s.registry.Known(med) {
+			status = output.Unknown
+		}
+		results = append(results, output.Result{Medallion: med, Trips: counts[med], Status: status})
+	}
+	return results
 }
 
-func (s *TripService) getFromDBByPickUpDate(ctx context.Context, medallion string, pickUpDate time.Time) (output.Result, error) {
-	result, err := s.dbGetter.TripsByMedallionsOnPickUpDate(ctx, medallion, pickUpDate)
+// getFromDBByPickUpDate returns trips by medallion with zero filled for medallions without trips.
+func (s *TripService) getFromDBByPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis) (map[string]int, error) {
+	results, err := s.dbGetter.TripsByMedallionsOnPickUpDate(ctx, medallions, pickUpDate, basis)
 	if err != nil {
-		return output.Result{}, err
+		return nil, err
+	}
+	counts := make(map[string]int, len(medallions))
+	for _, med := range medallions {
+		counts[med] = 0
+	}
+	for _, r := range results {
+		counts[r.Medallion] = r.Trips
+	}
+	go s.cachePickUpDate(ctx, counts, pickUpDate, basis)
+	return counts, nil
+}
+
+func (s *TripService) cachePickUpDate(ctx context.Context, counts map[string]int, pickUpDate time.Time, basis output.Basis) {
+	for med, tripCount := range counts {
+		s.cacheSetter.Set(ctx, cache.PickUpDates, cache.DateKey(med, pickUpDate, basis), tripCount)
 	}
-	go s.cacheSetter.Set(ctx, key(medallion, pickUpDate), result.Trips)
-	return result, err
 }
 
 // TripsByMedallion get the number of trips for medallions.
 // Check cache entries first before finding in DB.
 // By pass cache with byPassCache flag equals true.
-// Cache key is the medallion.
+// Cache key is cache.TotalKey of the medallion.
 // Query DB for cache misses.
+// Results follow the order of medallions and medallions without trips are reported as unknown.
 func (s *TripService) TripsByMedallion(ctx context.Context, medallions []string, byPassCache bool) ([]output.Result, error) {
-	var results []output.Result
-	var dbResults []output.Result
+	counts := make(map[string]int, len(medallions))
 	var dbMedallions []string
-	var err error
 	if byPassCache {
-		results, err = s.getFromDBByMedallion(ctx, medallions)
+		dbMedallions = medallions
+	} else {
+		for _, med := range medallions {
+			tripCount, err := s.cacheGetter.Get(ctx, cache.TotalKey(med))
+			if failure.Is(err, failure.ErrCacheMiss) {
+				dbMedallions = append(dbMedallions, med)
+			} else {
+				counts[med] = tripCount
+			}
+		}
+	}
+	if len(dbMedallions) > 0 {
+		dbCounts, err := s.getFromDBByMedallion(ctx, dbMedallions)
 		if err != nil {
 			s.logger.Error("Error finding trips for medallions", zap.Strings("medallions", medallions))
 			return []output.Result{}, err
 		}
+		for med, tripCount := range dbCounts {
+			counts[med] = tripCount
+		}
+	}
+	results := make([]output.Result, 0, len(medallions))
+	for _, med := range medallions {
+		status := output.Known
+		if counts[med] == 0 {
+			status = output.Unknown
+		}
+		results = append(results, output.Result{Medallion: med, Trips: counts[med], Status: status})
+	}
+	return results, nil
+}
+
+// getFromDBByMedallion returns trips by medallion with zero filled for medallions without trips.
+func (s *TripService) getFromDBByMedallion(ctx context.Context, medallions []string) (map[string]int, error) {
+	results, err := s.dbGetter.TripsByMedallion(ctx, medallions)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(medallions))
+	for _, med := range medallions {
+		counts[med] = 0
+	}
+	for _, r := range results {
+		counts[r.Medallion] = r.Trips
+	}
+	go s.cacheCounts(ctx, counts)
+	return counts, nil
+}
+
+func (s *TripService) cacheCounts(ctx context.Context, counts map[string]int) {
+	for med, tripCount := range counts {
+		s.cacheSetter.Set(ctx, cache.Totals, cache.TotalKey(med), tripCount)
+	}
+}
+
+// TripsByMedallionsInDateRange get the number of trips for medallions with pickup or dropoff date per basis in the inclusive range.
+// The range runs from midnight starting from to midnight ending to in the time zone of the dates.
+// Check cache entries first before finding in DB.
+// By pass cache with byPassCache flag equals true.
+// Cache key is cache.RangeKey of the medallion, date range, basis and time zone.
+// Query DB for cache misses.
+// Results follow the order of medallions and medallions without trips are reported with zero trips and their status.
+func (s *TripService) TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Result, error) {
+	counts := make(map[string]int, len(medallions))
+	var dbMedallions []string
+	if byPassCache {
+		dbMedallions = medallions
 	} else {
-		var tripCount int
 		for _, med := range medallions {
-			tripCount, err = s.cacheGetter.Get(ctx, med)
-			if err != nil && err.Error() == keyNotFound {
+			tripCount, err := s.cacheGetter.Get(ctx, cache.RangeKey(med, from, to, basis))
+			if failure.Is(err, failure.ErrCacheMiss) {
 				dbMedallions = append(dbMedallions, med)
 			} else {
-				results = append(results, output.Result{Medallion: med, Trips: tripCount})
+				counts[med] = tripCount
 			}
 		}
-		if len(dbMedallions) > 0 {
-			dbResults, err = s.getFromDBByMedallion(ctx, dbMedallions)
-			if err != nil {
-				s.logger.Error("Error finding trips for medallions", zap.Strings("medallions", medallions))
-				return []output.Result{}, err
-			}
+	}
+	if len(dbMedallions) > 0 {
+		dbCounts, err := s.getFromDBByDateRange(ctx, dbMedallions, from, to, basis)
+		if err != nil {
+			s.logger.Error("Error finding trips for medallions in date range", zap.Strings("medallions", medallions), zap.Time("from", from), zap.Time("to", to))
+			return []output.Result{}, err
+		}
+		for med, tripCount := range dbCounts {
+			counts[med] = tripCount
 		}
-		results = append(results, dbResults...)
 	}
+	return s.inOrder(medallions, counts), nil
+}
 
-	return results, nil
+// getFromDBByDateRange returns trips by medallion with zero filled for medallions without trips.
+func (s *TripService) getFromDBByDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (map[string]int, error) {
+	results, err := s.dbGetter.TripsByMedallionsInDateRange(ctx, medallions, from, to, basis)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(medallions))
+	for _, med := range medallions {
+		counts[med] = 0
+	}
+	for _, r := range results {
+		counts[r.Medallion] = r.Trips
+	}
+	go s.cacheRangeCounts(ctx, counts, from, to, basis)
+	return counts, nil
+}
 
+func (s *TripService) cacheRangeCounts(ctx context.Context, counts map[string]int, from, to time.Time, basis output.Basis) {
+	for med, tripCount := range counts {
+		s.cacheSetter.Set(ctx, cache.Ranges, cache.RangeKey(med, from, to, basis), tripCount)
+	}
 }
 
-func (s *TripService) getFromDBByMedallion(ctx context.Context, medallions []string) ([]output.Result, error) {
-	results, err := s.dbGetter.TripsByMedallion(ctx, medallions)
+// TripsByMedallionPerDay get the number of trips for a medallion for each pickup or dropoff date per basis in the inclusive range.
+// Dates without trips are reported with zero trips.
+// Dates are counted in the time zone the trips were recorded in, each date is cached under cache.DateKey
+// of the medallion, the midnight starting the date in that time zone and basis, the key of the same date
+// counted by TripsByMedallionsOnPickUpDate.
+// Query DB once for the span of dates missing in cache.
+func (s *TripService) TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.DailyResult, error) {
+	dates := days(from, to)
+	counts := make(map[string]int, len(dates))
+	var missed []time.Time
+	if byPassCache {
+		missed = dates
+	} else {
+		for _, d := range dates {
+			tripCount, err := s.cacheGetter.Get(ctx, cache.DateKey(medallion, s.recorded(d), basis))
+			if failure.Is(err, failure.ErrCacheMiss) {
+				missed = append(missed, d)
+			} else {
+				counts[d.Format(dateLayout)] = tripCount
+			}
+		}
+	}
+	if len(missed) > 0 {
+		dbCounts, err := s.getFromDBPerDay(ctx, medallion, missed[0], missed[len(missed)-1], basis)
+		if err != nil {
+			s.logger.Error("Error finding daily trips", zap.String("medallion", medallion), zap.Time("from", from), zap.Time("to", to))
+			return []output.DailyResult{}, err
+		}
+		for date, tripCount := range dbCounts {
+			counts[date] = tripCount
+		}
+	}
+	results := make([]output.DailyResult, 0, len(dates))
+	for _, d := range dates {
+		date := d.Format(dateLayout)
+		results = append(results, output.DailyResult{Date: date, Trips: counts[date]})
+	}
+	return results, nil
+}
+
+// getFromDBPerDay returns trips by date with zero filled for dates without trips.
+func (s *TripService) getFromDBPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis) (map[string]int, error) {
+	results, err := s.dbGetter.TripsByMedallionPerDay(ctx, medallion, from, to, basis)
 	if err != nil {
 		return nil, err
 	}
-	go s.cacheMedallions(ctx, results)
+	counts := make(map[string]int)
+	for _, d := range days(from, to) {
+		counts[d.Format(dateLayout)] = 0
+	}
+	for _, r := range results {
+		counts[r.Date] = r.Trips
+	}
+	go s.cacheDays(ctx, medallion, counts, basis)
+	return counts, nil
+}
+
+func (s *TripService) cacheDays(ctx context.Context, medallion string, counts map[string]int, basis output.Basis) {
+	for date, tripCount := range counts {
+		d, err := time.Parse(dateLayout, date)
+		if err != nil {
+			s.logger.Error("Error caching daily trips", zap.String("medallion", medallion), zap.String("date", date))
+			continue
+		}
+		s.cacheSetter.Set(ctx, cache.PickUpDates, cache.DateKey(medallion, s.recorded(d), basis), tripCount)
+	}
+}
+
+// recorded returns the midnight starting date in the time zone the trips were recorded in.
+func (s *TripService) recorded(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.zone)
+}
+
+// days lists each date between from and to inclusive.
+func days(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+// PickUpHistogram get the number of trips for medallions by hour of day and day of week of pick up or drop off per basis.
+// Zero from or to leaves the date range open on that side.
+func (s *TripService) PickUpHistogram(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (output.Histogram, error) {
+	result, err := s.dbGetter.PickUpHistogram(ctx, medallions, from, to, basis)
+	if err != nil {
+		s.logger.Error("Error finding pick up histogram", zap.Strings("medallions", medallions), zap.Time("from", from), zap.Time("to", to))
+		return output.Histogram{}, err
+	}
+	return result, nil
+}
+
+// StreamTripsByMedallions get the number of trips for any number of medallions.
+// Zero from or to counts trips for all dates, cached like TripsByMedallion.
+// Otherwise trips are counted for the inclusive range per basis in the time zone the trips were recorded in,
+// cached like TripsByMedallionsInDateRange.
+// fn is called with the cache hits first and then with the DB results of each chunk as it completes.
+// Each call follows the order of medallions and medallions without trips are reported with zero trips and their status.
+func (s *TripService) StreamTripsByMedallions(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool, fn func([]output.Result) error) error {
+	allDates := from.IsZero() || to.IsZero()
+	if !allDates {
+		from, to = s.recorded(from), s.recorded(to)
+	}
+	counts := make(map[string]int)
+	var cached, dbMedallions []string
+	if byPassCache {
+		dbMedallions = medallions
+	} else {
+		for _, med := range medallions {
+			cacheKey := cache.TotalKey(med)
+			if !allDates {
+				cacheKey = cache.RangeKey(med, from, to, basis)
+			}
+			tripCount, err := s.cacheGetter.Get(ctx, cacheKey)
+			if failure.Is(err, failure.ErrCacheMiss) {
+				dbMedallions = append(dbMedallions, med)
+			} else {
+				cached = append(cached, med)
+				counts[med] = tripCount
+			}
+		}
+	}
+	if len(cached) > 0 {
+		if err := fn(s.inOrder(cached, counts)); err != nil {
+			return err
+		}
+	}
+	if len(dbMedallions) == 0 {
+		return nil
+	}
+	err := s.dbGetter.TripsByMedallionsInChunks(ctx, dbMedallions, from, to, basis, func(chunk []string, res []output.Result) error {
+		counts := make(map[string]int, len(chunk))
+		for _, med := range chunk {
+			counts[med] = 0
+		}
+		for _, r := range res {
+			counts[r.Medallion] = r.Trips
+		}
+		if allDates {
+			go s.cacheCounts(ctx, counts)
+		} else {
+			go s.cacheRangeCounts(ctx, counts, from, to, basis)
+		}
+		return fn(s.inOrder(chunk, counts))
+	})
+	if err != nil {
+		s.logger.Error("Error streaming trips for medallions", zap.Int("medallions", len(medallions)), zap.Time("from", from), zap.Time("to", to))
+		return err
+	}
+	return nil
+}
+
+// Trips get a page of at most limit trips for a medallion following the cursor.
+// Trips are ordered and filtered by pick up or drop off per basis.
+// The next cursor is only set when more trips are available, trips sharing date times across pages are listed once.
+// A first page without trips for a medallion unknown to the registry returns failure.ErrNotFound.
+func (s *TripService) Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) (output.TripPage, error) {
+	trips, err := s.dbGetter.Trips(ctx, medallion, from, to, basis, after, limit+1)
+	if err != nil {
+		s.logger.Error("Error finding trip records", zap.String("medallion", medallion), zap.Time("from", from), zap.Time("to", to))
+		return output.TripPage{}, err
+	}
+	if len(trips) == 0 && after == nil && !s.registry.Known(medallion) {
+		return output.TripPage{}, errors.Wrapf(failure.ErrNotFound, "medallion %s", medallion)
+	}
+	page := output.TripPage{Trips: trips}
+	if len(trips) > limit {
+		page.Trips = trips[:limit]
+		page.NextCursor = output.NextCursor(page.Trips, after).Encode()
+	}
+	if page.Trips == nil {
+		page.Trips = []output.Trip{}
+	}
+	return page, nil
+}
+
+// TopMedallions get the limit medallions with the most trips by pick up or drop off date per basis ranked by trips.
+// Medallions with the same trips share a rank.
+// Check cache entries first before finding in DB, rankings expire from cache after the time to live of cache.Rankings.
+// By pass cache with byPassCache flag equals true.
+func (s *TripService) TopMedallions(ctx context.Context, from, to time.Time, basis output.Basis, limit int, byPassCache bool) ([]output.RankedResult, error) {
+	cacheKey := cache.RankingKey(from, to, basis, limit)
+	if !byPassCache {
+		ranking, err := s.cacheGetter.GetRanking(ctx, cacheKey)
+		if err == nil {
+			return ranking, nil
+		}
+		if !failure.Is(err, failure.ErrCacheMiss) {
+			s.logger.Error("Error finding cached ranking", zap.String("key", cacheKey), zap.Error(err))
+		}
+	}
+	results, err := s.dbGetter.TopMedallions(ctx, from, to, basis, limit)
+	if err != nil {
+		s.logger.Error("Error finding top medallions", zap.Time("from", from), zap.Time("to", to), zap.Int("limit", limit))
+		return []output.RankedResult{}, err
+	}
+	ranking := make([]output.RankedResult, 0, len(results))
+	for i, r := range results {
+		rank := i + 1
+		if i > 0 && r.Trips == results[i-1].Trips {
+			rank = ranking[i-1].Rank
+		}
+		ranking = append(ranking, output.RankedResult{Rank: rank, Medallion: r.Medallion, Trips: r.Trips})
+	}
+	go s.cacheSetter.SetRanking(ctx, cacheKey, ranking)
+	return ranking, nil
+}
+
+// TripsByDriver get the number of trips and the medallions operated for drivers.
+// Check cache entries first before finding in DB.
+// By pass cache with byPassCache flag equals true.
+// Query DB for cache misses, results follow the order of licenses.
+func (s *TripService) TripsByDriver(ctx context.Context, licenses []string, byPassCache bool) ([]output.DriverResult, error) {
+	found := make(map[string]output.DriverResult, len(licenses))
+	var dbLicenses []string
+	if byPassCache {
+		dbLicenses = licenses
+	} else {
+		for _, license := range licenses {
+			result, err := s.cacheGetter.GetDriver(ctx, cache.DriverKey(license))
+			if failure.Is(err, failure.ErrCacheMiss) {
+				dbLicenses = append(dbLicenses, license)
+			} else {
+				found[license] = result
+			}
+		}
+	}
+	if len(dbLicenses) > 0 {
+		dbResults, err := s.dbGetter.TripsByDriver(ctx, dbLicenses)
+		if err != nil {
+			s.logger.Error("Error finding trips for drivers", zap.Strings("licenses", licenses))
+			return []output.DriverResult{}, err
+		}
+		go s.cacheDrivers(ctx, dbResults)
+		for _, r := range dbResults {
+			found[r.HackLicense] = r
+		}
+	}
+	var results []output.DriverResult
+	for _, license := range licenses {
+		if r, ok := found[license]; ok {
+			results = append(results, r)
+		}
+	}
 	return results, nil
 }
 
-func (s *TripService) cacheMedallions(ctx context.Context, res []output.Result) {
+func (s *TripService) cacheDrivers(ctx context.Context, res []output.DriverResult) {
 	for _, r := range res {
-		s.cacheSetter.Set(ctx, r.Medallion, r.Trips)
+		s.cacheSetter.SetDriver(ctx, cache.DriverKey(r.HackLicense), r)
+	}
+}
+
+// TripsByDriverOnPickUpDate get the number of trips and the medallions operated for a driver by pickup or dropoff date per basis.
+// Check cache entries first before finding in DB.
+// By pass cache with byPassCache flag equals true.
+func (s *TripService) TripsByDriverOnPickUpDate(ctx context.Context, license string, pickUpDate time.Time, basis output.Basis, byPassCache bool) (output.DriverResult, error) {
+	cacheKey := cache.DriverDateKey(license, pickUpDate, basis)
+	if !byPassCache {
+		result, err := s.cacheGetter.GetDriver(ctx, cacheKey)
+		if !failure.Is(err, failure.ErrCacheMiss) {
+			return result, nil
+		}
+	}
+	result, err := s.dbGetter.TripsByDriverOnPickUpDate(ctx, license, pickUpDate, basis)
+	if err != nil {
+		s.logger.Error("Error finding trips for driver", zap.String("license", license), zap.Time("pickupdate", pickUpDate))
+		return output.DriverResult{}, err
+	}
+	go s.cacheSetter.SetDriver(ctx, cacheKey, result)
+	return result, nil
+}
+
+// TripsInArea get the number of trips with pick up or drop off per basis inside the area and in the inclusive date range.
+// Empty medallions counts trips of all medallions.
+// Trips per medallion are reported with perMedallion flag equals true or when medallions are given.
+func (s *TripService) TripsInArea(ctx context.Context, area geo.Area, from, to time.Time, basis output.Basis, medallions []string, perMedallion bool) (output.AreaResult, error) {
+	results, err := s.dbGetter.TripsInArea(ctx, area, from, to, basis, medallions)
+	if err != nil {
+		s.logger.Error("Error finding trips in area", zap.Any("area", area), zap.Time("from", from), zap.Time("to", to))
+		return output.AreaResult{}, err
+	}
+	var result output.AreaResult
+	for _, r := range results {
+		result.Trips += r.Trips
 	}
+	if perMedallion || len(medallions) > 0 {
+		result.Medallions = results
+	}
+	return result, nil
+}
+
+// Metrics get the trip figures for each medallion with pickup or dropoff date per basis in the inclusive range.
+// Zero from or to counts trips for all dates.
+// Check cache entries first before finding in DB.
+// By pass cache with byPassCache flag equals true.
+// Results follow the order of medallions and medallions without trips are reported with zero figures.
+func (s *TripService) Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, byPassCache bool) ([]output.Metrics, error) {
+	metrics := make(map[string]output.Metrics, len(medallions))
+	var dbMedallions []string
+	if byPassCache {
+		dbMedallions = medallions
+	} else {
+		for _, med := range medallions {
+			m, err := s.cacheGetter.GetMetrics(ctx, cache.MetricsKey(med, from, to, basis))
+			if failure.Is(err, failure.ErrCacheMiss) {
+				dbMedallions = append(dbMedallions, med)
+			} else {
+				metrics[med] = m
+			}
+		}
+	}
+	if len(dbMedallions) > 0 {
+		results, err := s.dbGetter.Metrics(ctx, dbMedallions, from, to, basis)
+		if err != nil {
+			s.logger.Error("Error finding metrics", zap.Strings("medallions", medallions), zap.Time("from", from), zap.Time("to", to))
+			return []output.Metrics{}, err
+		}
+		dbMetrics := make(map[string]output.Metrics, len(dbMedallions))
+		for _, med := range dbMedallions {
+			dbMetrics[med] = output.Metrics{Medallion: med}
+		}
+		for _, m := range results {
+			dbMetrics[m.Medallion] = m
+		}
+		for med, m := range dbMetrics {
+			metrics[med] = m
+		}
+		go s.cacheMetrics(ctx, dbMetrics, from, to, basis)
+	}
+	results := make([]output.Metrics, 0, len(medallions))
+	for _, med := range medallions {
+		results = append(results, metrics[med])
+	}
+	return results, nil
 }
 
-// key is built by concatenate medallion + pickUpDate.
-func key(medallion string, pickUpDate time.Time) string {
-	return fmt.Sprintf("%s%d%d%d", medallion, pickUpDate.Year(), pickUpDate.Month(), pickUpDate.Day())
+func (s *TripService) cacheMetrics(ctx context.Context, metrics map[string]output.Metrics, from, to time.Time, basis output.Basis) {
+	for med, m := range metrics {
+		s.cacheSetter.SetMetrics(ctx, cache.MetricsKey(med, from, to, basis), m)
+	}
 }
@@ -12,15 +12,20 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/geo"
 	"github.com/nikhil-github/api-cab-data/pkg/output"
 	"github.com/nikhil-github/api-cab-data/pkg/service"
 )
 
 func TestTripsByMedOnPickUpDate(t *testing.T) {
 	t.Parallel()
-	pDate := time.Date(2013, 12, 31, 0, 1, 0, 0, time.UTC)
+	pDate := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
 	type args struct {
-		Medallions  string
+		Medallions  []string
 		PickUpDate  time.Time
 		ByPassCache bool
 	}
@@ -30,7 +35,870 @@ func TestTripsByMedOnPickUpDate(t *testing.T) {
 	}
 	type want struct {
 		Error  string
-		Result output.Result
+		Result []output.Result
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB",
+			Args: args{Medallions: []string{"med1"}, PickUpDate: pDate, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsPdate([]string{"med1"}, pDate, output.PickUp).Return([]output.Result{{Medallion: "med1", Trips: 5}}, nil).Once()
+					cs.OnSet(cache.PickUpDates, "date:med1:pickup:20131231:UTC", 5)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med1", Trips: 5, Status: output.Known}}},
+		},
+		{
+			Name: "Get from Cache",
+			Args: args{Medallions: []string{"med2"}, PickUpDate: pDate, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med2:pickup:20131231:UTC").Return(10, nil).Once()
+				},
+			},
+			Want: want{Result: []output.Result{{Medallion: "med2", Trips: 10, Status: output.Known}}},
+		},
+		{
+			Name: "Cached per time zone",
+			Args: args{Medallions: []string{"med2"}, PickUpDate: time.Date(2013, 12, 31, 0, 0, 0, 0, newYork), ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med2:pickup:20131231:America/New_York").Return(8, nil).Once()
+				},
+			},
+			Want: want{Result: []output.Result{{Medallion: "med2", Trips: 8, Status: output.Known}}},
+		},
+		{
+			Name: "Cache Missed",
+			Args: args{Medallions: []string{"med2"}, PickUpDate: pDate, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med2:pickup:20131231:UTC").Return(0, failure.ErrCacheMiss)
+					d.OnTripsPdate([]string{"med2"}, pDate, output.PickUp).Return([]output.Result{{Medallion: "med2", Trips: 5}}, nil).Once()
+					cs.OnSet(cache.PickUpDates, "date:med2:pickup:20131231:UTC", 5)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med2", Trips: 5, Status: output.Known}}},
+		},
+		{
+			Name: "Partial Cache Missed",
+			Args: args{Medallions: []string{"med4", "med5", "med6"}, PickUpDate: pDate, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med4:pickup:20131231:UTC").Return(0, failure.ErrCacheMiss)
+					cg.OnGet("date:med5:pickup:20131231:UTC").Return(7, nil)
+					cg.OnGet("date:med6:pickup:20131231:UTC").Return(0, failure.ErrCacheMiss)
+					d.OnTripsPdate([]string{"med4", "med6"}, pDate, output.PickUp).Return([]output.Result{{Medallion: "med4", Trips: 2}}, nil).Once()
+					cs.OnSet(cache.PickUpDates, "date:med4:pickup:20131231:UTC", 2)
+					cs.OnSet(cache.PickUpDates, "date:med6:pickup:20131231:UTC", 0)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(2)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med4", Trips: 2, Status: output.Known}, {Medallion: "med5", Trips: 7, Status: output.Known}, {Medallion: "med6", Trips: 0, Status: output.Known}}},
+		},
+		{
+			Name: "Unknown Medallion",
+			Args: args{Medallions: []string{"med0", "med8"}, PickUpDate: pDate, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med0:pickup:20131231:UTC").Return(0, nil)
+					cg.OnGet("date:med8:pickup:20131231:UTC").Return(0, nil)
+				},
+			},
+			Want: want{Result: []output.Result{{Medallion: "med0", Trips: 0, Status: output.Unknown}, {Medallion: "med8", Trips: 0, Status: output.Known}}},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallions: []string{"med3"}, PickUpDate: pDate, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsPdate([]string{"med3"}, pDate, output.PickUp).Return([]output.Result{}, errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsByMedallionsOnPickUpDate(context.Background(), tt.Args.Medallions, tt.Args.PickUpDate, output.PickUp, tt.Args.ByPassCache)
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+
+}
+
+func TestTripsByMedallions(t *testing.T) {
+	t.Parallel()
+	res := output.Result{Medallion: "med2", Trips: 10, Status: output.Known}
+	type args struct {
+		Medallions  []string
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Result []output.Result
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB",
+			Args: args{Medallions: []string{"med1"}, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsMed([]string{"med1"}).Return([]output.Result{{Medallion: "med1", Trips: 5}}, nil).Once()
+					cs.OnSet(cache.Totals, "total:med1", 5)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med1", Trips: 5, Status: output.Known}}},
+		},
+		{
+			Name: "Cache and DB in request order",
+			Args: args{Medallions: []string{"med4", "med2", "med5"}, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("total:med4").Return(0, failure.ErrCacheMiss).Once()
+					cg.OnGet("total:med2").Return(10, nil).Once()
+					cg.OnGet("total:med5").Return(0, failure.ErrCacheMiss).Once()
+					d.OnTripsMed([]string{"med4", "med5"}).Return([]output.Result{{Medallion: "med4", Trips: 3}}, nil).Once()
+					cs.OnSet(cache.Totals, "total:med4", 3)
+					cs.OnSet(cache.Totals, "total:med5", 0)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(2)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med4", Trips: 3, Status: output.Known}, res, {Medallion: "med5", Trips: 0, Status: output.Unknown}}},
+		},
+		{
+			Name: "Get from Cache",
+			Args: args{Medallions: []string{"med2"}, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("total:med2").Return(10, nil).Once()
+				},
+			},
+			Want: want{Result: []output.Result{res}},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallions: []string{"med3"}, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsMed([]string{"med3"}).Return([]output.Result{}, errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsByMedallion(context.Background(), tt.Args.Medallions, tt.Args.ByPassCache)
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+
+}
+
+func TestTripsByMedallionsInDateRange(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Medallions  []string
+		Basis       output.Basis
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Result []output.Result
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB",
+			Args: args{Medallions: []string{"med1"}, Basis: output.PickUp, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsRange([]string{"med1"}, from, to, output.PickUp).Return([]output.Result{{Medallion: "med1", Trips: 5}}, nil).Once()
+					cs.OnSet(cache.Ranges, "range:med1:pickup:20131201:20131231:UTC", 5)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med1", Trips: 5, Status: output.Known}}},
+		},
+		{
+			Name: "Get from Cache and DB",
+			Args: args{Medallions: []string{"med2", "med3"}, Basis: output.PickUp, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("range:med2:pickup:20131201:20131231:UTC").Return(10, nil).Once()
+					cg.OnGet("range:med3:pickup:20131201:20131231:UTC").Return(0, failure.ErrCacheMiss).Once()
+					d.OnTripsRange([]string{"med3"}, from, to, output.PickUp).Return([]output.Result{{Medallion: "med3", Trips: 2}}, nil).Once()
+					cs.OnSet(cache.Ranges, "range:med3:pickup:20131201:20131231:UTC", 2)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med2", Trips: 10, Status: output.Known}, {Medallion: "med3", Trips: 2, Status: output.Known}}},
+		},
+		{
+			Name: "Zero trips in range",
+			Args: args{Medallions: []string{"med5", "med0"}, Basis: output.PickUp, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsRange([]string{"med5", "med0"}, from, to, output.PickUp).Return([]output.Result{}, nil).Once()
+					cs.OnSet(cache.Ranges, "range:med5:pickup:20131201:20131231:UTC", 0)
+					cs.OnSet(cache.Ranges, "range:med0:pickup:20131201:20131231:UTC", 0)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(2)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med5", Trips: 0, Status: output.Known}, {Medallion: "med0", Trips: 0, Status: output.Unknown}}},
+		},
+		{
+			Name: "Drop off cached apart from pick up",
+			Args: args{Medallions: []string{"med2"}, Basis: output.DropOff, ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("range:med2:dropoff:20131201:20131231:UTC").Return(0, failure.ErrCacheMiss).Once()
+					d.OnTripsRange([]string{"med2"}, from, to, output.DropOff).Return([]output.Result{{Medallion: "med2", Trips: 9}}, nil).Once()
+					cs.OnSet(cache.Ranges, "range:med2:dropoff:20131201:20131231:UTC", 9)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Result{{Medallion: "med2", Trips: 9, Status: output.Known}}},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallions: []string{"med4"}, Basis: output.PickUp, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsRange([]string{"med4"}, from, to, output.PickUp).Return([]output.Result{}, errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsByMedallionsInDateRange(context.Background(), tt.Args.Medallions, from, to, tt.Args.Basis, tt.Args.ByPassCache)
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestTripsByMedallionPerDay(t *testing.T) {
+	t.Parallel()
+	// daily counts of trips recorded in New York share the keys of the New York dates of TripsByMedallionsOnPickUpDate.
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 3, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		Medallion   string
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Result []output.DailyResult
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB with zero filled days",
+			Args: args{Medallion: "med1", ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsPerDay("med1", from, to, output.PickUp).Return([]output.DailyResult{{Date: "2013-12-01", Trips: 4}, {Date: "2013-12-03", Trips: 7}}, nil).Once()
+					cs.OnSet(cache.PickUpDates, "date:med1:pickup:20131201:America/New_York", 4)
+					cs.OnSet(cache.PickUpDates, "date:med1:pickup:20131202:America/New_York", 0)
+					cs.OnSet(cache.PickUpDates, "date:med1:pickup:20131203:America/New_York", 7)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(3)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.DailyResult{{Date: "2013-12-01", Trips: 4}, {Date: "2013-12-02", Trips: 0}, {Date: "2013-12-03", Trips: 7}}},
+		},
+		{
+			Name: "Get from Cache",
+			Args: args{Medallion: "med2", ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med2:pickup:20131201:America/New_York").Return(1, nil).Once()
+					cg.OnGet("date:med2:pickup:20131202:America/New_York").Return(2, nil).Once()
+					cg.OnGet("date:med2:pickup:20131203:America/New_York").Return(3, nil).Once()
+				},
+			},
+			Want: want{Result: []output.DailyResult{{Date: "2013-12-01", Trips: 1}, {Date: "2013-12-02", Trips: 2}, {Date: "2013-12-03", Trips: 3}}},
+		},
+		{
+			Name: "Cache Missed for some days",
+			Args: args{Medallion: "med3", ByPassCache: false},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("date:med3:pickup:20131201:America/New_York").Return(1, nil).Once()
+					cg.OnGet("date:med3:pickup:20131202:America/New_York").Return(0, failure.ErrCacheMiss).Once()
+					cg.OnGet("date:med3:pickup:20131203:America/New_York").Return(0, failure.ErrCacheMiss).Once()
+					d.OnTripsPerDay("med3", from.AddDate(0, 0, 1), to, output.PickUp).Return([]output.DailyResult{{Date: "2013-12-02", Trips: 5}}, nil).Once()
+					cs.OnSet(cache.PickUpDates, "date:med3:pickup:20131202:America/New_York", 5)
+					cs.OnSet(cache.PickUpDates, "date:med3:pickup:20131203:America/New_York", 0)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(2)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.DailyResult{{Date: "2013-12-01", Trips: 1}, {Date: "2013-12-02", Trips: 5}, {Date: "2013-12-03", Trips: 0}}},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallion: "med4", ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsPerDay("med4", from, to, output.PickUp).Return([]output.DailyResult{}, errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, newYork, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsByMedallionPerDay(context.Background(), tt.Args.Medallion, from, to, output.PickUp, tt.Args.ByPassCache)
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestPickUpHistogram(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	hist := output.Histogram{Medallions: []string{"med1"}}
+	hist.HourOfDay[8] = 2
+	hist.DayOfWeek[1] = 2
+	type args struct {
+		Medallions []string
+	}
+	type fields struct {
+		MockOperations func(d *dbMock)
+	}
+	type want struct {
+		Error  string
+		Result output.Histogram
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB",
+			Args: args{Medallions: []string{"med1"}},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnHistogram([]string{"med1"}, from, to, output.PickUp).Return(hist, nil).Once()
+				},
+			},
+			Want: want{Result: hist},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallions: []string{"med2"}},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnHistogram([]string{"med2"}, from, to, output.PickUp).Return(output.Histogram{}, errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.PickUpHistogram(context.Background(), tt.Args.Medallions, from, to, output.PickUp)
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestStreamTripsByMedallions(t *testing.T) {
+	t.Parallel()
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "Unable to load time zone")
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	recordedFrom := time.Date(2013, 12, 1, 0, 0, 0, 0, newYork)
+	recordedTo := time.Date(2013, 12, 31, 0, 0, 0, 0, newYork)
+	type args struct {
+		Medallions  []string
+		From        time.Time
+		To          time.Time
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Chunks [][]output.Result
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get all dates from DB",
+			Args: args{Medallions: []string{"med1"}, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsChunks([]string{"med1"}, time.Time{}, time.Time{}, output.PickUp).Return(nil, []output.Result{{Medallion: "med1", Trips: 5}}).Once()
+					cs.OnSet(cache.Totals, "total:med1", 5)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Chunks: [][]output.Result{{{Medallion: "med1", Trips: 5, Status: output.Known}}}},
+		},
+		{
+			Name: "Get date range from Cache and DB",
+			Args: args{Medallions: []string{"med2", "med3"}, From: from, To: to},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("range:med2:pickup:20131201:20131231:America/New_York").Return(10, nil).Once()
+					cg.OnGet("range:med3:pickup:20131201:20131231:America/New_York").Return(0, failure.ErrCacheMiss).Once()
+					d.OnTripsChunks([]string{"med3"}, recordedFrom, recordedTo, output.PickUp).Return(nil, []output.Result{{Medallion: "med3", Trips: 2}}).Once()
+					cs.OnSet(cache.Ranges, "range:med3:pickup:20131201:20131231:America/New_York", 2)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Chunks: [][]output.Result{{{Medallion: "med2", Trips: 10, Status: output.Known}}, {{Medallion: "med3", Trips: 2, Status: output.Known}}}},
+		},
+		{
+			Name: "Medallions without trips in order with their status",
+			Args: args{Medallions: []string{"med0", "med7", "med8"}, From: from, To: to},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("range:med0:pickup:20131201:20131231:America/New_York").Return(0, failure.ErrCacheMiss).Once()
+					cg.OnGet("range:med7:pickup:20131201:20131231:America/New_York").Return(0, failure.ErrCacheMiss).Once()
+					cg.OnGet("range:med8:pickup:20131201:20131231:America/New_York").Return(0, failure.ErrCacheMiss).Once()
+					d.OnTripsChunks([]string{"med0", "med7", "med8"}, recordedFrom, recordedTo, output.PickUp).Return(nil, []output.Result{{Medallion: "med8", Trips: 3}}).Once()
+					cs.OnSet(cache.Ranges, "range:med0:pickup:20131201:20131231:America/New_York", 0)
+					cs.OnSet(cache.Ranges, "range:med7:pickup:20131201:20131231:America/New_York", 0)
+					cs.OnSet(cache.Ranges, "range:med8:pickup:20131201:20131231:America/New_York", 3)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(3)
+				},
+				CacheSet: true,
+			},
+			Want: want{Chunks: [][]output.Result{{
+				{Medallion: "med0", Trips: 0, Status: output.Unknown},
+				{Medallion: "med7", Trips: 0, Status: output.Known},
+				{Medallion: "med8", Trips: 3, Status: output.Known},
+			}}},
+		},
+		{
+			Name: "All from Cache",
+			Args: args{Medallions: []string{"med4"}},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("total:med4").Return(1, nil).Once()
+				},
+			},
+			Want: want{Chunks: [][]output.Result{{{Medallion: "med4", Trips: 1, Status: output.Known}}}},
+		},
+		{
+			Name: "Cached zero trips",
+			Args: args{Medallions: []string{"med0"}},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGet("total:med0").Return(0, nil).Once()
+				},
+			},
+			Want: want{Chunks: [][]output.Result{{{Medallion: "med0", Trips: 0, Status: output.Unknown}}}},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallions: []string{"med5"}, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTripsChunks([]string{"med5"}, time.Time{}, time.Time{}, output.PickUp).Return(errors.New("error"), nil)
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, newYork, known, &cacheGet, &cacheSet, zap.NewNop())
+			var chunks [][]output.Result
+			err := svc.StreamTripsByMedallions(context.Background(), tt.Args.Medallions, tt.Args.From, tt.Args.To, output.PickUp, tt.Args.ByPassCache, func(res []output.Result) error {
+				chunks = append(chunks, res)
+				return nil
+			})
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Chunks, chunks, "results")
+		})
+	}
+}
+
+func TestTrips(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	trip1 := output.Trip{Medallion: "med1", PickUpDateTime: time.Date(2013, 12, 1, 10, 0, 0, 0, time.UTC), DropOffDateTime: time.Date(2013, 12, 1, 10, 12, 0, 0, time.UTC)}
+	trip2 := output.Trip{Medallion: "med1", PickUpDateTime: time.Date(2013, 12, 1, 11, 0, 0, 0, time.UTC), DropOffDateTime: time.Date(2013, 12, 1, 11, 5, 0, 0, time.UTC)}
+	trip3 := output.Trip{Medallion: "med1", PickUpDateTime: time.Date(2013, 12, 2, 9, 0, 0, 0, time.UTC), DropOffDateTime: time.Date(2013, 12, 2, 9, 30, 0, 0, time.UTC)}
+	tie := output.Trip{Medallion: "med1", PickUpDateTime: trip2.PickUpDateTime, DropOffDateTime: trip2.DropOffDateTime, PassengerCount: 3}
+	after := &output.Cursor{PickUpDateTime: trip1.PickUpDateTime, DropOffDateTime: trip1.DropOffDateTime, Skip: 1}
+	afterTie := &output.Cursor{PickUpDateTime: trip2.PickUpDateTime, DropOffDateTime: trip2.DropOffDateTime, Skip: 1}
+	type args struct {
+		Medallion string
+		After     *output.Cursor
+		Limit     int
+	}
+	type fields struct {
+		MockOperations func(d *dbMock)
+	}
+	type want struct {
+		Error    string
+		NotFound bool
+		Result   output.TripPage
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "More trips available",
+			Args: args{Medallion: "med1", Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med1", from, to, output.PickUp, (*output.Cursor)(nil), 3).Return([]output.Trip{trip1, trip2, trip3}, nil).Once()
+				},
+			},
+			Want: want{Result: output.TripPage{Trips: []output.Trip{trip1, trip2}, NextCursor: output.NewCursor(trip2).Encode()}},
+		},
+		{
+			Name: "Last page",
+			Args: args{Medallion: "med1", After: after, Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med1", from, to, output.PickUp, after, 3).Return([]output.Trip{trip2, trip3}, nil).Once()
+				},
+			},
+			Want: want{Result: output.TripPage{Trips: []output.Trip{trip2, trip3}}},
+		},
+		{
+			Name: "Trips sharing date times are skipped by the next cursor",
+			Args: args{Medallion: "med1", After: after, Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med1", from, to, output.PickUp, after, 3).Return([]output.Trip{trip2, tie, tie}, nil).Once()
+				},
+			},
+			Want: want{Result: output.TripPage{Trips: []output.Trip{trip2, tie}, NextCursor: output.Cursor{PickUpDateTime: trip2.PickUpDateTime, DropOffDateTime: trip2.DropOffDateTime, Skip: 2}.Encode()}},
+		},
+		{
+			Name: "Page of trips sharing the cursor date times adds to its skip",
+			Args: args{Medallion: "med1", After: afterTie, Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med1", from, to, output.PickUp, afterTie, 3).Return([]output.Trip{tie, tie, trip3}, nil).Once()
+				},
+			},
+			Want: want{Result: output.TripPage{Trips: []output.Trip{tie, tie}, NextCursor: output.Cursor{PickUpDateTime: trip2.PickUpDateTime, DropOffDateTime: trip2.DropOffDateTime, Skip: 3}.Encode()}},
+		},
+		{
+			Name: "No trips",
+			Args: args{Medallion: "med1", Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med1", from, to, output.PickUp, (*output.Cursor)(nil), 3).Return([]output.Trip(nil), nil).Once()
+				},
+			},
+			Want: want{Result: output.TripPage{Trips: []output.Trip{}}},
+		},
+		{
+			Name: "Unknown medallion",
+			Args: args{Medallion: "med0", Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med0", from, to, output.PickUp, (*output.Cursor)(nil), 3).Return([]output.Trip(nil), nil).Once()
+				},
+			},
+			Want: want{Error: "medallion med0: not found", NotFound: true},
+		},
+		{
+			Name: "Failure",
+			Args: args{Medallion: "med1", Limit: 2},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTrips("med1", from, to, output.PickUp, (*output.Cursor)(nil), 3).Return([]output.Trip(nil), errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.Trips(context.Background(), tt.Args.Medallion, from, to, output.PickUp, tt.Args.After, tt.Args.Limit)
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				assert.Equal(t, tt.Want.NotFound, failure.Is(err, failure.ErrNotFound), "not found")
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestTopMedallions(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	ranking := []output.RankedResult{{Rank: 1, Medallion: "med1", Trips: 9}, {Rank: 2, Medallion: "med2", Trips: 4}, {Rank: 2, Medallion: "med3", Trips: 4}, {Rank: 4, Medallion: "med4", Trips: 1}}
+	type args struct {
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Result []output.RankedResult
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB with ties",
+			Args: args{ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTop(from, to, output.PickUp, 4).Return([]output.Result{{Medallion: "med1", Trips: 9}, {Medallion: "med2", Trips: 4}, {Medallion: "med3", Trips: 4}, {Medallion: "med4", Trips: 1}}, nil).Once()
+					cs.OnSetRanking("ranking:pickup:4:20131201:20131231", ranking)
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: ranking},
+		},
+		{
+			Name: "Get from Cache",
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGetRanking("ranking:pickup:4:20131201:20131231").Return(ranking, nil).Once()
+				},
+			},
+			Want: want{Result: ranking},
+		},
+		{
+			Name: "Cache Missed",
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGetRanking("ranking:pickup:4:20131201:20131231").Return([]output.RankedResult(nil), failure.ErrCacheMiss).Once()
+					d.OnTop(from, to, output.PickUp, 4).Return([]output.Result{{Medallion: "med1", Trips: 9}}, nil).Once()
+					cs.OnSetRanking("ranking:pickup:4:20131201:20131231", []output.RankedResult{{Rank: 1, Medallion: "med1", Trips: 9}})
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.RankedResult{{Rank: 1, Medallion: "med1", Trips: 9}}},
+		},
+		{
+			Name: "Failure",
+			Args: args{ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnTop(from, to, output.PickUp, 4).Return([]output.Result{}, errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TopMedallions(context.Background(), from, to, output.PickUp, 4, tt.Args.ByPassCache)
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestTripsByDriver(t *testing.T) {
+	t.Parallel()
+	res := output.DriverResult{HackLicense: "lic2", Trips: 10, Medallions: []string{"med1"}}
+	type args struct {
+		Licenses    []string
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Result []output.DriverResult
 	}
 	testTable := []struct {
 		Name   string
@@ -40,49 +908,51 @@ func TestTripsByMedOnPickUpDate(t *testing.T) {
 	}{
 		{
 			Name: "Get from DB",
-			Args: args{Medallions: "med1", PickUpDate: pDate, ByPassCache: true},
+			Args: args{Licenses: []string{"lic1"}, ByPassCache: true},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					d.OnTripsPdate("med1", pDate).Return(output.Result{Medallion: "med1", Trips: 5}, nil).Once()
-					cs.OnSet("med120131231", 5)
+					d.OnTripsDriver([]string{"lic1"}).Return([]output.DriverResult{{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1", "med2"}}}, nil).Once()
+					cs.OnSetDriver("driver:lic1", output.DriverResult{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1", "med2"}})
 					cs.wg = sync.WaitGroup{}
 					cs.wg.Add(1)
 				},
 				CacheSet: true,
 			},
-			Want: want{Result: output.Result{Medallion: "med1", Trips: 5}},
+			Want: want{Result: []output.DriverResult{{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1", "med2"}}}},
 		},
 		{
 			Name: "Get from Cache",
-			Args: args{Medallions: "med2", PickUpDate: pDate, ByPassCache: false},
+			Args: args{Licenses: []string{"lic2"}, ByPassCache: false},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					cg.OnGet("med220131231").Return(10, nil).Once()
+					cg.OnGetDriver("driver:lic2").Return(res, nil).Once()
 				},
 			},
-			Want: want{Result: output.Result{Medallion: "med2", Trips: 10}},
+			Want: want{Result: []output.DriverResult{res}},
 		},
 		{
-			Name: "Cache Missed",
-			Args: args{Medallions: "med2", PickUpDate: pDate, ByPassCache: false},
+			Name: "Cache hits and DB results in request order",
+			Args: args{Licenses: []string{"lic1", "lic2", "lic4"}, ByPassCache: false},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					cg.OnGet("med220131231").Return(0, errors.New("Key not found in cache"))
-					d.OnTripsPdate("med2", pDate).Return(output.Result{Medallion: "med2", Trips: 5}, nil).Once()
-					cs.OnSet("med220131231", 5)
+					cg.OnGetDriver("driver:lic1").Return(output.DriverResult{}, failure.ErrCacheMiss).Once()
+					cg.OnGetDriver("driver:lic2").Return(res, nil).Once()
+					cg.OnGetDriver("driver:lic4").Return(output.DriverResult{}, failure.ErrCacheMiss).Once()
+					d.OnTripsDriver([]string{"lic1", "lic4"}).Return([]output.DriverResult{{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1"}}}, nil).Once()
+					cs.OnSetDriver("driver:lic1", output.DriverResult{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1"}})
 					cs.wg = sync.WaitGroup{}
 					cs.wg.Add(1)
 				},
 				CacheSet: true,
 			},
-			Want: want{Result: output.Result{Medallion: "med2", Trips: 5}},
+			Want: want{Result: []output.DriverResult{{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1"}}, res}},
 		},
 		{
 			Name: "Failure",
-			Args: args{Medallions: "med3", PickUpDate: pDate, ByPassCache: true},
+			Args: args{Licenses: []string{"lic3"}, ByPassCache: true},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					d.OnTripsPdate("med3", pDate).Return(output.Result{}, errors.New("error"))
+					d.OnTripsDriver([]string{"lic3"}).Return([]output.DriverResult{}, errors.New("error"))
 				},
 			},
 			Want: want{Error: "error"},
@@ -95,8 +965,8 @@ func TestTripsByMedOnPickUpDate(t *testing.T) {
 			var cacheGet cacheGetMock
 			var cacheSet cacheSetMock
 			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
-			svc := service.New(&db, &cacheGet, &cacheSet, zap.NewNop())
-			result, err := svc.TripsByMedallionsOnPickUpDate(context.Background(), tt.Args.Medallions, tt.Args.PickUpDate, tt.Args.ByPassCache)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsByDriver(context.Background(), tt.Args.Licenses, tt.Args.ByPassCache)
 			if tt.Fields.CacheSet {
 				cacheSet.wg.Wait()
 			}
@@ -108,14 +978,13 @@ func TestTripsByMedOnPickUpDate(t *testing.T) {
 			assert.Equal(t, tt.Want.Result, result, "results")
 		})
 	}
-
 }
 
-func TestTripsByMedallions(t *testing.T) {
+func TestTripsByDriverOnPickUpDate(t *testing.T) {
 	t.Parallel()
-	res := output.Result{Medallion: "med2", Trips: 10}
+	pDate := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
 	type args struct {
-		Medallions  []string
+		License     string
 		ByPassCache bool
 	}
 	type fields struct {
@@ -124,7 +993,7 @@ func TestTripsByMedallions(t *testing.T) {
 	}
 	type want struct {
 		Error  string
-		Result []output.Result
+		Result output.DriverResult
 	}
 	testTable := []struct {
 		Name   string
@@ -134,34 +1003,49 @@ func TestTripsByMedallions(t *testing.T) {
 	}{
 		{
 			Name: "Get from DB",
-			Args: args{Medallions: []string{"med1"}, ByPassCache: true},
+			Args: args{License: "lic1", ByPassCache: true},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					d.OnTripsMed([]string{"med1"}).Return([]output.Result{{Medallion: "med1", Trips: 5}}, nil).Once()
-					cs.OnSet("med1", 5)
+					d.OnTripsDriverPdate("lic1", pDate, output.PickUp).Return(output.DriverResult{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1"}}, nil).Once()
+					cs.OnSetDriver("driverdate:lic1:pickup:20131231:UTC", output.DriverResult{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1"}})
 					cs.wg = sync.WaitGroup{}
 					cs.wg.Add(1)
 				},
 				CacheSet: true,
 			},
-			Want: want{Result: []output.Result{{Medallion: "med1", Trips: 5}}},
+			Want: want{Result: output.DriverResult{HackLicense: "lic1", Trips: 5, Medallions: []string{"med1"}}},
 		},
 		{
 			Name: "Get from Cache",
-			Args: args{Medallions: []string{"med2"}, ByPassCache: false},
+			Args: args{License: "lic2"},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					cg.OnGet("med2").Return(10, nil).Once()
+					cg.OnGetDriver("driverdate:lic2:pickup:20131231:UTC").Return(output.DriverResult{HackLicense: "lic2", Trips: 1, Medallions: []string{"med2"}}, nil).Once()
 				},
 			},
-			Want: want{Result: []output.Result{res}},
+			Want: want{Result: output.DriverResult{HackLicense: "lic2", Trips: 1, Medallions: []string{"med2"}}},
+		},
+		{
+			Name: "Cache Missed",
+			Args: args{License: "lic3"},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGetDriver("driverdate:lic3:pickup:20131231:UTC").Return(output.DriverResult{}, failure.ErrCacheMiss).Once()
+					d.OnTripsDriverPdate("lic3", pDate, output.PickUp).Return(output.DriverResult{HackLicense: "lic3", Medallions: []string{}}, nil).Once()
+					cs.OnSetDriver("driverdate:lic3:pickup:20131231:UTC", output.DriverResult{HackLicense: "lic3", Medallions: []string{}})
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: output.DriverResult{HackLicense: "lic3", Medallions: []string{}}},
 		},
 		{
 			Name: "Failure",
-			Args: args{Medallions: []string{"med3"}, ByPassCache: true},
+			Args: args{License: "lic4", ByPassCache: true},
 			Fields: fields{
 				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
-					d.OnTripsMed([]string{"med3"}).Return([]output.Result{}, errors.New("error"))
+					d.OnTripsDriverPdate("lic4", pDate, output.PickUp).Return(output.DriverResult{}, errors.New("error"))
 				},
 			},
 			Want: want{Error: "error"},
@@ -174,8 +1058,180 @@ func TestTripsByMedallions(t *testing.T) {
 			var cacheGet cacheGetMock
 			var cacheSet cacheSetMock
 			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
-			svc := service.New(&db, &cacheGet, &cacheSet, zap.NewNop())
-			result, err := svc.TripsByMedallion(context.Background(), tt.Args.Medallions, tt.Args.ByPassCache)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsByDriverOnPickUpDate(context.Background(), tt.Args.License, pDate, output.PickUp, tt.Args.ByPassCache)
+			if tt.Fields.CacheSet {
+				cacheSet.wg.Wait()
+			}
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestTripsInArea(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	area := geo.NewRadiusArea(geo.Point{Lat: 40.75, Lng: -73.95}, 1000)
+	counts := []output.Result{{Medallion: "med1", Trips: 3}, {Medallion: "med2", Trips: 2}}
+	type args struct {
+		Medallions   []string
+		PerMedallion bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock)
+	}
+	type want struct {
+		Error  string
+		Result output.AreaResult
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Total only",
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTripsArea(area, from, to, output.PickUp, []string(nil)).Return(counts, nil).Once()
+				},
+			},
+			Want: want{Result: output.AreaResult{Trips: 5}},
+		},
+		{
+			Name: "Per medallion",
+			Args: args{PerMedallion: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTripsArea(area, from, to, output.PickUp, []string(nil)).Return(counts, nil).Once()
+				},
+			},
+			Want: want{Result: output.AreaResult{Trips: 5, Medallions: counts}},
+		},
+		{
+			Name: "Filtered by medallions",
+			Args: args{Medallions: []string{"med1", "med2"}},
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTripsArea(area, from, to, output.PickUp, []string{"med1", "med2"}).Return(counts, nil).Once()
+				},
+			},
+			Want: want{Result: output.AreaResult{Trips: 5, Medallions: counts}},
+		},
+		{
+			Name: "DB Error",
+			Fields: fields{
+				MockOperations: func(d *dbMock) {
+					d.OnTripsArea(area, from, to, output.PickUp, []string(nil)).Return([]output.Result(nil), errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.TripsInArea(context.Background(), area, from, to, output.PickUp, tt.Args.Medallions, tt.Args.PerMedallion)
+			if tt.Want.Error != "" {
+				assert.EqualError(t, err, tt.Want.Error)
+				return
+			}
+			require.NoError(t, err, "should not return an error")
+			assert.Equal(t, tt.Want.Result, result, "results")
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)
+	first := time.Date(2013, 12, 1, 8, 0, 0, 0, time.UTC)
+	last := time.Date(2013, 12, 30, 22, 0, 0, 0, time.UTC)
+	med1 := output.Metrics{Medallion: "med1", Trips: 2, TotalDistance: 5.5, AverageDistance: 2.75, TotalDuration: 1200, AverageDuration: 600, Passengers: 3, FirstPickUp: &first, LastPickUp: &last}
+	type args struct {
+		Medallions  []string
+		From        time.Time
+		To          time.Time
+		ByPassCache bool
+	}
+	type fields struct {
+		MockOperations func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock)
+		CacheSet       bool
+	}
+	type want struct {
+		Error  string
+		Result []output.Metrics
+	}
+	testTable := []struct {
+		Name   string
+		Args   args
+		Fields fields
+		Want   want
+	}{
+		{
+			Name: "Get from DB with zero filled medallions",
+			Args: args{Medallions: []string{"med2", "med1"}, From: from, To: to, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnMetrics([]string{"med2", "med1"}, from, to, output.PickUp).Return([]output.Metrics{med1}, nil).Once()
+					cs.OnSetMetrics("metrics:med1:pickup:20131201:20131231:UTC", med1)
+					cs.OnSetMetrics("metrics:med2:pickup:20131201:20131231:UTC", output.Metrics{Medallion: "med2"})
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(2)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Metrics{{Medallion: "med2"}, med1}},
+		},
+		{
+			Name: "Get from Cache and DB for all dates",
+			Args: args{Medallions: []string{"med1", "med3"}},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					cg.OnGetMetrics("metrics:med1").Return(med1, nil).Once()
+					cg.OnGetMetrics("metrics:med3").Return(output.Metrics{}, failure.ErrCacheMiss).Once()
+					d.OnMetrics([]string{"med3"}, time.Time{}, time.Time{}, output.PickUp).Return([]output.Metrics{{Medallion: "med3", Trips: 1}}, nil).Once()
+					cs.OnSetMetrics("metrics:med3", output.Metrics{Medallion: "med3", Trips: 1})
+					cs.wg = sync.WaitGroup{}
+					cs.wg.Add(1)
+				},
+				CacheSet: true,
+			},
+			Want: want{Result: []output.Metrics{med1, {Medallion: "med3", Trips: 1}}},
+		},
+		{
+			Name: "DB Error",
+			Args: args{Medallions: []string{"med4"}, From: from, To: to, ByPassCache: true},
+			Fields: fields{
+				MockOperations: func(d *dbMock, cg *cacheGetMock, cs *cacheSetMock) {
+					d.OnMetrics([]string{"med4"}, from, to, output.PickUp).Return([]output.Metrics(nil), errors.New("error"))
+				},
+			},
+			Want: want{Error: "error"},
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			var db dbMock
+			var cacheGet cacheGetMock
+			var cacheSet cacheSetMock
+			tt.Fields.MockOperations(&db, &cacheGet, &cacheSet)
+			svc := service.New(&db, time.UTC, known, &cacheGet, &cacheSet, zap.NewNop())
+			result, err := svc.Metrics(context.Background(), tt.Args.Medallions, tt.Args.From, tt.Args.To, output.PickUp, tt.Args.ByPassCache)
 			if tt.Fields.CacheSet {
 				cacheSet.wg.Wait()
 			}
@@ -185,22 +1241,31 @@ func TestTripsByMedallions(t *testing.T) {
 			}
 			require.NoError(t, err, "should not return an error")
 			assert.Equal(t, tt.Want.Result, result, "results")
+			cacheSet.AssertExpectations(t)
 		})
 	}
+}
+
+// known is the registry of the test medallions, med0 was never seen.
+var known = registryMock{"med1": true, "med2": true, "med3": true, "med4": true, "med5": true, "med6": true, "med7": true, "med8": true, "med9": true}
 
+type registryMock map[string]bool
+
+func (r registryMock) Known(medallion string) bool {
+	return r[medallion]
 }
 
 type dbMock struct {
 	mock.Mock
 }
 
-func (d *dbMock) TripsByMedallionsOnPickUpDate(ctx context.Context, medallion string, pickUpDate time.Time) (output.Result, error) {
-	args := d.Called(ctx, medallion, pickUpDate)
-	return args.Get(0).(output.Result), args.Error(1)
+func (d *dbMock) TripsByMedallionsOnPickUpDate(ctx context.Context, medallions []string, pickUpDate time.Time, basis output.Basis) ([]output.Result, error) {
+	args := d.Called(ctx, medallions, pickUpDate, basis)
+	return args.Get(0).([]output.Result), args.Error(1)
 }
 
-func (d *dbMock) OnTripsPdate(medallion string, pickUpDate time.Time) *mock.Call {
-	return d.On("TripsByMedallionsOnPickUpDate", mock.AnythingOfTypeArgument("*context.emptyCtx"), medallion, pickUpDate)
+func (d *dbMock) OnTripsPdate(medallions []string, pickUpDate time.Time, basis output.Basis) *mock.Call {
+	return d.On("TripsByMedallionsOnPickUpDate", mock.Anything, medallions, pickUpDate, basis)
 }
 
 func (d *dbMock) TripsByMedallion(ctx context.Context, medallions []string) ([]output.Result, error) {
@@ -209,7 +1274,101 @@ func (d *dbMock) TripsByMedallion(ctx context.Context, medallions []string) ([]o
 }
 
 func (d *dbMock) OnTripsMed(medallions []string) *mock.Call {
-	return d.On("TripsByMedallion", mock.AnythingOfTypeArgument("*context.emptyCtx"), medallions)
+	return d.On("TripsByMedallion", mock.Anything, medallions)
+}
+
+func (d *dbMock) TripsByMedallionsInDateRange(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) ([]output.Result, error) {
+	args := d.Called(ctx, medallions, from, to, basis)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (d *dbMock) OnTripsRange(medallions []string, from, to time.Time, basis output.Basis) *mock.Call {
+	return d.On("TripsByMedallionsInDateRange", mock.Anything, medallions, from, to, basis)
+}
+
+func (d *dbMock) TripsByMedallionPerDay(ctx context.Context, medallion string, from, to time.Time, basis output.Basis) ([]output.DailyResult, error) {
+	args := d.Called(ctx, medallion, from, to, basis)
+	return args.Get(0).([]output.DailyResult), args.Error(1)
+}
+
+func (d *dbMock) OnTripsPerDay(medallion string, from, to time.Time, basis output.Basis) *mock.Call {
+	return d.On("TripsByMedallionPerDay", mock.Anything, medallion, from, to, basis)
+}
+
+func (d *dbMock) PickUpHistogram(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) (output.Histogram, error) {
+	args := d.Called(ctx, medallions, from, to, basis)
+	return args.Get(0).(output.Histogram), args.Error(1)
+}
+
+func (d *dbMock) OnHistogram(medallions []string, from, to time.Time, basis output.Basis) *mock.Call {
+	return d.On("PickUpHistogram", mock.Anything, medallions, from, to, basis)
+}
+
+func (d *dbMock) TripsByMedallionsInChunks(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis, fn func([]string, []output.Result) error) error {
+	args := d.Called(ctx, medallions, from, to, basis)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(medallions, args.Get(1).([]output.Result))
+}
+
+// OnTripsChunks expects a chunked query, Return(err, chunk) answers with a single chunk of results for every medallion.
+func (d *dbMock) OnTripsChunks(medallions []string, from, to time.Time, basis output.Basis) *mock.Call {
+	return d.On("TripsByMedallionsInChunks", mock.Anything, medallions, from, to, basis)
+}
+
+func (d *dbMock) Trips(ctx context.Context, medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) ([]output.Trip, error) {
+	args := d.Called(ctx, medallion, from, to, basis, after, limit)
+	return args.Get(0).([]output.Trip), args.Error(1)
+}
+
+func (d *dbMock) OnTrips(medallion string, from, to time.Time, basis output.Basis, after *output.Cursor, limit int) *mock.Call {
+	return d.On("Trips", mock.Anything, medallion, from, to, basis, after, limit)
+}
+
+func (d *dbMock) TopMedallions(ctx context.Context, from, to time.Time, basis output.Basis, limit int) ([]output.Result, error) {
+	args := d.Called(ctx, from, to, basis, limit)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (d *dbMock) OnTop(from, to time.Time, basis output.Basis, limit int) *mock.Call {
+	return d.On("TopMedallions", mock.Anything, from, to, basis, limit)
+}
+
+func (d *dbMock) TripsByDriver(ctx context.Context, licenses []string) ([]output.DriverResult, error) {
+	args := d.Called(ctx, licenses)
+	return args.Get(0).([]output.DriverResult), args.Error(1)
+}
+
+func (d *dbMock) OnTripsDriver(licenses []string) *mock.Call {
+	return d.On("TripsByDriver", mock.Anything, licenses)
+}
+
+func (d *dbMock) TripsByDriverOnPickUpDate(ctx context.Context, license string, pickUpDate time.Time, basis output.Basis) (output.DriverResult, error) {
+	args := d.Called(ctx, license, pickUpDate, basis)
+	return args.Get(0).(output.DriverResult), args.Error(1)
+}
+
+func (d *dbMock) OnTripsDriverPdate(license string, pickUpDate time.Time, basis output.Basis) *mock.Call {
+	return d.On("TripsByDriverOnPickUpDate", mock.Anything, license, pickUpDate, basis)
+}
+
+func (d *dbMock) TripsInArea(ctx context.Context, area geo.Area, from, to time.Time, basis output.Basis, medallions []string) ([]output.Result, error) {
+	args := d.Called(ctx, area, from, to, basis, medallions)
+	return args.Get(0).([]output.Result), args.Error(1)
+}
+
+func (d *dbMock) OnTripsArea(area geo.Area, from, to time.Time, basis output.Basis, medallions []string) *mock.Call {
+	return d.On("TripsInArea", mock.Anything, area, from, to, basis, medallions)
+}
+
+func (d *dbMock) Metrics(ctx context.Context, medallions []string, from, to time.Time, basis output.Basis) ([]output.Metrics, error) {
+	args := d.Called(ctx, medallions, from, to, basis)
+	return args.Get(0).([]output.Metrics), args.Error(1)
+}
+
+func (d *dbMock) OnMetrics(medallions []string, from, to time.Time, basis output.Basis) *mock.Call {
+	return d.On("Metrics", mock.Anything, medallions, from, to, basis)
 }
 
 type cacheGetMock struct {
@@ -222,7 +1381,34 @@ func (cg *cacheGetMock) Get(ctx context.Context, key string) (int, error) {
 }
 
 func (cg *cacheGetMock) OnGet(key string) *mock.Call {
-	return cg.On("Get", mock.AnythingOfTypeArgument("*context.emptyCtx"), key)
+	return cg.On("Get", mock.Anything, key)
+}
+
+func (cg *cacheGetMock) GetRanking(ctx context.Context, key string) ([]output.RankedResult, error) {
+	args := cg.Called(ctx, key)
+	return args.Get(0).([]output.RankedResult), args.Error(1)
+}
+
+func (cg *cacheGetMock) OnGetRanking(key string) *mock.Call {
+	return cg.On("GetRanking", mock.Anything, key)
+}
+
+func (cg *cacheGetMock) GetDriver(ctx context.Context, key string) (output.DriverResult, error) {
+	args := cg.Called(ctx, key)
+	return args.Get(0).(output.DriverResult), args.Error(1)
+}
+
+func (cg *cacheGetMock) OnGetDriver(key string) *mock.Call {
+	return cg.On("GetDriver", mock.Anything, key)
+}
+
+func (cg *cacheGetMock) GetMetrics(ctx context.Context, key string) (output.Metrics, error) {
+	args := cg.Called(ctx, key)
+	return args.Get(0).(output.Metrics), args.Error(1)
+}
+
+func (cg *cacheGetMock) OnGetMetrics(key string) *mock.Call {
+	return cg.On("GetMetrics", mock.Anything, key)
 }
 
 type cacheSetMock struct {
@@ -230,12 +1416,39 @@ type cacheSetMock struct {
 	wg sync.WaitGroup
 }
 
-func (cs *cacheSetMock) Set(ctx context.Context, key string, val int) {
-	cs.Called(ctx, key, val)
+func (cs *cacheSetMock) Set(ctx context.Context, category cache.Category, key string, val int) {
+	cs.Called(ctx, category, key, val)
 	cs.wg.Done()
 	return
 }
 
-func (cs *cacheSetMock) OnSet(key string, val int) *mock.Call {
-	return cs.On("Set", mock.AnythingOfTypeArgument("*context.emptyCtx"), key, val)
+func (cs *cacheSetMock) OnSet(category cache.Category, key string, val int) *mock.Call {
+	return cs.On("Set", mock.Anything, category, key, val)
+}
+
+func (cs *cacheSetMock) SetRanking(ctx context.Context, key string, val []output.RankedResult) {
+	cs.Called(ctx, key, val)
+	cs.wg.Done()
+}
+
+func (cs *cacheSetMock) OnSetRanking(key string, val []output.RankedResult) *mock.Call {
+	return cs.On("SetRanking", mock.Anything, key, val)
+}
+
+func (cs *cacheSetMock) SetDriver(ctx context.Context, key string, val output.DriverResult) {
+	cs.Called(ctx, key, val)
+	cs.wg.Done()
+}
+
+func (cs *cacheSetMock) OnSetDriver(key string, val output.DriverResult) *mock.Call {
+	return cs.On("SetDriver", mock.Anything, key, val)
+}
+
+func (cs *cacheSetMock) SetMetrics(ctx context.Context, key string, val output.Metrics) {
+	cs.Called(ctx, key, val)
+	cs.wg.Done()
+}
+
+func (cs *cacheSetMock) OnSetMetrics(key string, val output.Metrics) *mock.Call {
+	return cs.On("SetMetrics", mock.Anything, key, val)
 }
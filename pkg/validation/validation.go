@@ -0,0 +1,206 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/output"
+)
+
+// MedallionLength is the length of a medallion, an MD5 hash in hex.
+const MedallionLength = 32
+
+// LicenseLength is the length of a hack license, an MD5 hash in hex.
+const LicenseLength = 32
+
+// Limits of a request, shared by the REST, GraphQL and gRPC APIs.
+const (
+	// MaxMedallions is the maximum number of medallions per request.
+	MaxMedallions = 100
+	// MaxBulkMedallions is the maximum number of medallions of a bulk query or a streamed request.
+	MaxBulkMedallions = 10000
+	// MaxDays is the longest date range served as a daily series.
+	MaxDays = 366
+)
+
+// DefaultZone is the time zone of a date when none is supplied, the time zone the trips were recorded in.
+const DefaultZone = "America/New_York"
+
+// dateLayout is the format of the dates of a request.
+const dateLayout = "2006-01-02"
+
+// maxListed is the most invalid medallions or licenses named in an error.
+const maxListed = 5
+
+// Medallion normalizes val to upper case and checks it is a medallion.
+func Medallion(val string) (string, error) {
+	return hash("medallion", val, MedallionLength)
+}
+
+// License normalizes val to upper case and checks it is a hack license.
+func License(val string) (string, error) {
+	return hash("license", val, LicenseLength)
+}
+
+// Medallions normalizes vals to upper case and drops empty and duplicate medallions, keeping the first of each in order.
+// It checks every medallion and that there are at least one and at most max medallions.
+func Medallions(vals []string, max int) ([]string, error) {
+	return hashes("medallions", vals, MedallionLength, max)
+}
+
+// Licenses normalizes vals to upper case and drops empty and duplicate hack licenses, keeping the first of each in order.
+// It checks every license and that there are at least one and at most max licenses.
+func Licenses(vals []string, max int) ([]string, error) {
+	return hashes("licenses", vals, LicenseLength, max)
+}
+
+func isHash(val string, length int) bool {
+	if len(val) != length {
+		return false
+	}
+	for _, c := range val {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func hash(field, val string, length int) (string, error) {
+	h := strings.ToUpper(strings.TrimSpace(val))
+	if len(h) == 0 {
+		return "", failure.Invalid(field, "missing "+field)
+	}
+	if !isHash(h, length) {
+		return "", failure.Invalid(field, fmt.Sprintf("%s is not a %d character hex %s", val, length, field))
+	}
+	return h, nil
+}
+
+func hashes(field string, vals []string, length, max int) ([]string, error) {
+	var valid, invalid []string
+	seen := make(map[string]bool, len(vals))
+	for _, val := range vals {
+		h := strings.ToUpper(strings.TrimSpace(val))
+		if len(h) == 0 || seen[h] {
+			continue
+		}
+		seen[h] = true
+		if !isHash(h, length) {
+			invalid = append(invalid, val)
+			continue
+		}
+		valid = append(valid, h)
+	}
+	switch {
+	case len(invalid) > 0:
+		return nil, failure.Invalid(field, notHashes(field, invalid, length))
+	case len(valid) == 0:
+		return nil, failure.Invalid(field, "missing "+field)
+	case len(valid) > max:
+		return nil, failure.Invalid(field, fmt.Sprintf("max number of %s is %d", field, max))
+	}
+	return valid, nil
+}
+
+func notHashes(field string, vals []string, length int) string {
+	listed := vals
+	if len(listed) > maxListed {
+		listed = listed[:maxListed]
+	}
+	reason := fmt.Sprintf("not %d character hex %s: %s", length, field, strings.Join(listed, ", "))
+	if len(vals) > maxListed {
+		reason += fmt.Sprintf(" and %d more", len(vals)-maxListed)
+	}
+	return reason
+}
+
+// Date parses val formatted as YYYY-MM-DD.
+func Date(val string) (time.Time, error) {
+	if len(val) == 0 {
+		return time.Time{}, errors.New("date missing")
+	}
+	return time.Parse(dateLayout, val)
+}
+
+// Dates parses the from and to dates of a range, zero time when both dates are missing.
+func Dates(fromVal, toVal string) (time.Time, time.Time, error) {
+	if len(fromVal) == 0 && len(toVal) == 0 {
+		return time.Time{}, time.Time{}, nil
+	}
+	from, err := Date(fromVal)
+	if err != nil {
+		return time.Time{}, time.Time{}, failure.Invalid("from", "invalid from date")
+	}
+	to, err := Date(toVal)
+	if err != nil {
+		return time.Time{}, time.Time{}, failure.Invalid("to", "invalid to date")
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, failure.Invalid("from", "from date is after to date")
+	}
+	return from, to, nil
+}
+
+// Zone loads the time zone named val, DefaultZone when val is empty.
+func Zone(val string) (*time.Location, error) {
+	if len(val) == 0 {
+		val = DefaultZone
+	}
+	return time.LoadLocation(val)
+}
+
+// InZone returns the midnight starting date in zone.
+func InZone(date time.Time, zone *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, zone)
+}
+
+// Basis checks val is a basis, pick up when val is empty.
+func Basis(val string) (output.Basis, error) {
+	switch basis := output.Basis(val); basis {
+	case "":
+		return output.PickUp, nil
+	case output.PickUp, output.DropOff:
+		return basis, nil
+	default:
+		return "", fmt.Errorf("basis must be %s or %s", output.PickUp, output.DropOff)
+	}
+}
+
+// Bounds are the first and last pick up dates of the loaded dataset.
+// The zero value does not bound dates.
+type Bounds struct {
+	First time.Time
+	Last  time.Time
+}
+
+// NewBounds parses the first and last dates formatted as YYYY-MM-DD.
+func NewBounds(first, last string) (Bounds, error) {
+	from, err := time.Parse(dateLayout, first)
+	if err != nil {
+		return Bounds{}, fmt.Errorf("invalid first date %s", first)
+	}
+	to, err := time.Parse(dateLayout, last)
+	if err != nil {
+		return Bounds{}, fmt.Errorf("invalid last date %s", last)
+	}
+	if from.After(to) {
+		return Bounds{}, fmt.Errorf("first date %s is after last date %s", first, last)
+	}
+	return Bounds{First: from, Last: to}, nil
+}
+
+// Date checks the date of field is within the bounds.
+func (b Bounds) Date(field string, date time.Time) error {
+	if b.First.IsZero() || date.IsZero() {
+		return nil
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(b.First) || day.After(b.Last) {
+		return failure.Invalid(field, fmt.Sprintf("date must be between %s and %s", b.First.Format(dateLayout), b.Last.Format(dateLayout)))
+	}
+	return nil
+}
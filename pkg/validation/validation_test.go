@@ -0,0 +1,212 @@
+package validation_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nikhil-github/api-cab-data/pkg/failure"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
+)
+
+const (
+	med1 = "D7D598CD99978BD012A87A76A7C891B7"
+	med2 = "5455D5FF2BD94D10B304A15D4B7F2735"
+)
+
+func TestMedallion(t *testing.T) {
+	type want struct {
+		Medallion string
+		Err       string
+	}
+	testTable := []struct {
+		Name string
+		Args string
+		Want want
+	}{
+		{Name: "Medallion", Args: med1, Want: want{Medallion: med1}},
+		{Name: "Lower case", Args: " d7d598cd99978bd012a87a76a7c891b7 ", Want: want{Medallion: med1}},
+		{Name: "Empty", Args: "", Want: want{Err: "invalid medallion: missing medallion"}},
+		{Name: "Too short", Args: "YYYY", Want: want{Err: "invalid medallion: YYYY is not a 32 character hex medallion"}},
+		{Name: "Not hex", Args: "G7D598CD99978BD012A87A76A7C891B7", Want: want{Err: "invalid medallion: G7D598CD99978BD012A87A76A7C891B7 is not a 32 character hex medallion"}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			medallion, err := validation.Medallion(tt.Args)
+			if tt.Want.Err != "" {
+				assert.EqualError(t, err, tt.Want.Err)
+				assert.True(t, failure.Is(err, failure.ErrInvalidInput), "invalid input")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want.Medallion, medallion)
+		})
+	}
+}
+
+func TestMedallions(t *testing.T) {
+	many := make([]string, 7)
+	for i := range many {
+		many[i] = fmt.Sprintf("BAD%d", i)
+	}
+	type args struct {
+		Vals []string
+		Max  int
+	}
+	type want struct {
+		Medallions []string
+		Err        string
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{Name: "Medallions in order", Args: args{Vals: []string{med2, med1}, Max: 2}, Want: want{Medallions: []string{med2, med1}}},
+		{Name: "Duplicates and case removed", Args: args{Vals: []string{med1, med2, "d7d598cd99978bd012a87a76a7c891b7", med2}, Max: 2}, Want: want{Medallions: []string{med1, med2}}},
+		{Name: "Empty entries dropped", Args: args{Vals: []string{"", med1, " "}, Max: 1}, Want: want{Medallions: []string{med1}}},
+		{Name: "Empty path segment", Args: args{Vals: []string{""}, Max: 1}, Want: want{Err: "invalid medallions: missing medallions"}},
+		{Name: "Too many", Args: args{Vals: []string{med1, med2}, Max: 1}, Want: want{Err: "invalid medallions: max number of medallions is 1"}},
+		{Name: "Invalid", Args: args{Vals: []string{med1, "YYYY", "ZZZZ"}, Max: 3}, Want: want{Err: "invalid medallions: not 32 character hex medallions: YYYY, ZZZZ"}},
+		{Name: "Many invalid", Args: args{Vals: many, Max: 10}, Want: want{Err: "invalid medallions: not 32 character hex medallions: BAD0, BAD1, BAD2, BAD3, BAD4 and 2 more"}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			medallions, err := validation.Medallions(tt.Args.Vals, tt.Args.Max)
+			if tt.Want.Err != "" {
+				assert.EqualError(t, err, tt.Want.Err)
+				assert.True(t, failure.Is(err, failure.ErrInvalidInput), "invalid input")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want.Medallions, medallions)
+		})
+	}
+}
+
+func TestBounds(t *testing.T) {
+	_, err := validation.NewBounds("2013-12-31", "2013-12-01")
+	assert.EqualError(t, err, "first date 2013-12-31 is after last date 2013-12-01")
+	_, err = validation.NewBounds("2013-12", "2013-12-31")
+	assert.EqualError(t, err, "invalid first date 2013-12")
+
+	bounds, err := validation.NewBounds("2013-12-01", "2013-12-31")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	type args struct {
+		Bounds validation.Bounds
+		Date   time.Time
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want string
+	}{
+		{Name: "First date", Args: args{Bounds: bounds, Date: time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)}},
+		{Name: "Last date in another zone", Args: args{Bounds: bounds, Date: time.Date(2013, 12, 31, 0, 0, 0, 0, newYork)}},
+		{Name: "Missing date", Args: args{Bounds: bounds}},
+		{Name: "Before first date", Args: args{Bounds: bounds, Date: time.Date(2013, 11, 30, 0, 0, 0, 0, time.UTC)}, Want: "invalid from: date must be between 2013-12-01 and 2013-12-31"},
+		{Name: "After last date", Args: args{Bounds: bounds, Date: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)}, Want: "invalid from: date must be between 2013-12-01 and 2013-12-31"},
+		{Name: "Unbounded", Args: args{Date: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.Args.Bounds.Date("from", tt.Args.Date)
+			if tt.Want != "" {
+				assert.EqualError(t, err, tt.Want)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	const (
+		lic1 = "2C6C0B3F6F5B7A1A5F4E3A6D8E2B1C0D"
+		lic2 = "6F0D7A3E4B1C2D9E8F7A6B5C4D3E2F1A"
+	)
+	type args struct {
+		Vals []string
+		Max  int
+	}
+	type want struct {
+		Licenses []string
+		Err      string
+	}
+	testTable := []struct {
+		Name string
+		Args args
+		Want want
+	}{
+		{Name: "Licenses in order", Args: args{Vals: []string{lic2, lic1}, Max: 2}, Want: want{Licenses: []string{lic2, lic1}}},
+		{Name: "Duplicates, case and empty entries removed", Args: args{Vals: []string{lic1, "", "2c6c0b3f6f5b7a1a5f4e3a6d8e2b1c0d", lic2}, Max: 2}, Want: want{Licenses: []string{lic1, lic2}}},
+		{Name: "Empty path segment", Args: args{Vals: []string{""}, Max: 1}, Want: want{Err: "invalid licenses: missing licenses"}},
+		{Name: "Too many", Args: args{Vals: []string{lic1, lic2}, Max: 1}, Want: want{Err: "invalid licenses: max number of licenses is 1"}},
+		{Name: "Invalid", Args: args{Vals: []string{lic1, "YYYY"}, Max: 2}, Want: want{Err: "invalid licenses: not 32 character hex licenses: YYYY"}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			licenses, err := validation.Licenses(tt.Args.Vals, tt.Args.Max)
+			if tt.Want.Err != "" {
+				assert.EqualError(t, err, tt.Want.Err)
+				assert.True(t, failure.Is(err, failure.ErrInvalidInput), "invalid input")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want.Licenses, licenses)
+		})
+	}
+	_, err := validation.License("YYYY")
+	assert.EqualError(t, err, "invalid license: YYYY is not a 32 character hex license")
+}
+
+func TestDates(t *testing.T) {
+	type want struct {
+		From time.Time
+		To   time.Time
+		Err  string
+	}
+	testTable := []struct {
+		Name string
+		Args [2]string
+		Want want
+	}{
+		{Name: "Range", Args: [2]string{"2013-12-01", "2013-12-31"}, Want: want{From: time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2013, 12, 31, 0, 0, 0, 0, time.UTC)}},
+		{Name: "Single day", Args: [2]string{"2013-12-01", "2013-12-01"}, Want: want{From: time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2013, 12, 1, 0, 0, 0, 0, time.UTC)}},
+		{Name: "Both missing", Args: [2]string{"", ""}},
+		{Name: "From missing", Args: [2]string{"", "2013-12-31"}, Want: want{Err: "invalid from: invalid from date"}},
+		{Name: "Invalid to", Args: [2]string{"2013-12-01", "2013-12"}, Want: want{Err: "invalid to: invalid to date"}},
+		{Name: "From after to", Args: [2]string{"2013-12-31", "2013-12-01"}, Want: want{Err: "invalid from: from date is after to date"}},
+	}
+	for _, tt := range testTable {
+		t.Run(tt.Name, func(t *testing.T) {
+			from, to, err := validation.Dates(tt.Args[0], tt.Args[1])
+			if tt.Want.Err != "" {
+				assert.EqualError(t, err, tt.Want.Err)
+				assert.True(t, failure.Is(err, failure.ErrInvalidInput), "invalid input")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want.From, from)
+			assert.Equal(t, tt.Want.To, to)
+		})
+	}
+}
+
+func TestZone(t *testing.T) {
+	newYork, err := validation.Zone("")
+	require.NoError(t, err)
+	assert.Equal(t, validation.DefaultZone, newYork.String())
+	utc, err := validation.Zone("UTC")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC.String(), utc.String())
+	_, err = validation.Zone("Mars/Olympus_Mons")
+	assert.Error(t, err)
+
+	assert.Equal(t, time.Date(2013, 11, 3, 0, 0, 0, 0, newYork), validation.InZone(time.Date(2013, 11, 3, 0, 0, 0, 0, time.UTC), newYork))
+}
@@ -0,0 +1,40 @@
+package wiring
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/nikhil-github/api-cab-data/pkg/cache"
+)
+
+// NewCache creates the cache of the configured backend.
+// The Redis backend is pinged so that a wrong address fails at start up rather than on every request.
+func NewCache(config CacheConfig, logger *zap.Logger) (cache.Store, error) {
+	cfg := cache.Config{
+		TTLs: map[cache.Category]time.Duration{
+			cache.Totals:      config.TTL.Totals,
+			cache.PickUpDates: config.TTL.PickUpDates,
+			cache.Ranges:      config.TTL.Ranges,
+			cache.Rankings:    config.TTL.Rankings,
+			cache.Drivers:     config.TTL.Drivers,
+			cache.Metrics:     config.TTL.Metrics,
+		},
+		MaxEntries: config.MaxEntries,
+		Namespace:  cache.Namespace{Version: config.Version, Tenant: config.Tenant, Dataset: config.Dataset},
+	}
+	switch config.Backend {
+	case "memory":
+		return cache.New(cfg), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: config.Redis.Addr, Password: config.Redis.Password, DB: config.Redis.DB})
+		if err := client.Ping().Err(); err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to redis on address %s", config.Redis.Addr)
+		}
+		return cache.NewRedis(client, cfg, logger), nil
+	default:
+		return nil, errors.Errorf("unknown cache backend %s", config.Backend)
+	}
+}
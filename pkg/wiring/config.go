@@ -8,17 +8,55 @@ type Config struct {
 	HTTP struct {
 		Port int `envconfig:"default=3000"`
 	}
+	GRPC struct {
+		Port int `envconfig:"default=9000"`
+	}
 	LOG struct {
 		Level string
 	}
+	// Admin token authorizes the admin endpoints, they are disabled without it.
+	Admin struct {
+		Token string `envconfig:"optional"`
+	}
+	Cache CacheConfig
 }
 
 // DBConfig wraps DB configs.
+// Zone is the time zone trip date times were recorded in.
+// CreateIndex creates the trip index at start up when it is missing.
 type DBConfig struct {
 	URL         string
+	Zone        string `envconfig:"default=America/New_York"`
+	CreateIndex bool   `envconfig:"default=false"`
 	Connections struct {
 		Idle     int           `envconfig:"default=10"`
 		Lifetime time.Duration `envconfig:"default=5m"`
 		Max      int           `envconfig:"default=20"`
 	}
 }
+
+// CacheConfig wraps cache configs.
+// Backend is memory, entries kept per replica, or redis, entries shared by replicas through Redis.
+// TTL is how long the entries of each category live, zero never expires.
+// MaxEntries bounds the number of in memory entries, the least recently used entry is evicted beyond it.
+// Version, Tenant and Dataset namespace the keys, bumping the version leaves the entries of previous versions unread.
+type CacheConfig struct {
+	Backend    string `envconfig:"default=memory"`
+	MaxEntries int    `envconfig:"default=100000"`
+	Version    int    `envconfig:"default=1"`
+	Tenant     string `envconfig:"optional"`
+	Dataset    string `envconfig:"optional"`
+	Redis      struct {
+		Addr     string `envconfig:"default=localhost:6379"`
+		Password string `envconfig:"optional"`
+		DB       int    `envconfig:"default=0"`
+	}
+	TTL struct {
+		Totals      time.Duration `envconfig:"default=6h"`
+		PickUpDates time.Duration `envconfig:"default=24h"`
+		Ranges      time.Duration `envconfig:"default=24h"`
+		Rankings    time.Duration `envconfig:"default=1h"`
+		Drivers     time.Duration `envconfig:"default=6h"`
+		Metrics     time.Duration `envconfig:"default=24h"`
+	}
+}
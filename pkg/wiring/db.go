@@ -1,12 +1,22 @@
 package wiring
 
 import (
+	"context"
 	"database/sql"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/nikhil-github/api-cab-data/pkg/database"
 )
 
+// TripIndexer provides methods to check and create the trip index.
+type TripIndexer interface {
+	HasTripIndex(ctx context.Context) (bool, error)
+	CreateTripIndex(ctx context.Context) error
+}
+
 // NewDatabase creates a new database
 func NewDatabase(config DBConfig) (*sqlx.DB, error) {
 	db, err := sql.Open("mysql", string(config.URL))
@@ -20,3 +30,26 @@ func NewDatabase(config DBConfig) (*sqlx.DB, error) {
 	dbx := sqlx.NewDb(db, "mysql")
 	return dbx, nil
 }
+
+// checkTripIndex logs a missing trip index, or creates it when create is true.
+// Date queries work without the index, only slower, so failures are logged and the app carries on.
+func checkTripIndex(ctx context.Context, indexer TripIndexer, create bool, logger *zap.Logger) {
+	ok, err := indexer.HasTripIndex(ctx)
+	if err != nil {
+		logger.Error("Failed to check trip index", zap.Error(err))
+		return
+	}
+	if ok {
+		return
+	}
+	if !create {
+		logger.Warn("Trip index on (medallion, pickup_datetime) is missing, date queries scan the table. Set DB_CREATE_INDEX=true to create it", zap.String("index", database.TripIndex))
+		return
+	}
+	logger.Info("Creating trip index", zap.String("index", database.TripIndex))
+	if err := indexer.CreateTripIndex(ctx); err != nil {
+		logger.Error("Failed to create trip index", zap.Error(err))
+		return
+	}
+	logger.Info("Created trip index", zap.String("index", database.TripIndex))
+}
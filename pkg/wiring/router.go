@@ -5,23 +5,49 @@ import (
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
+	"github.com/nikhil-github/api-cab-data/pkg/graph"
 	"github.com/nikhil-github/api-cab-data/pkg/handler"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
 )
 
 // Params represent router params.
 type Params struct {
-	Health health.Handler
-	Logger *zap.Logger
-	Svc    handler.Servicer
-	Cache  handler.Clearer
+	Health   health.Handler
+	Logger   *zap.Logger
+	Svc      handler.Servicer
+	Registry handler.Lister
+	Cache    handler.Clearer
+	// Inspector reports cache statistics and lists cache entries, the latter for admins holding AdminToken.
+	Inspector  handler.Inspector
+	AdminToken string
+	// Bounds are the dates of the loaded dataset, the zero value does not bound dates.
+	Bounds validation.Bounds
 }
 
 // NewRouter configure all router.
+// Every response carries a request ID and errors are RFC 7807 problems, unmatched routes and methods included.
 func NewRouter(params *Params) *mux.Router {
 	rtr := mux.NewRouter().StrictSlash(true)
+	rtr.Handle("/trips/v1/medallions", handler.Medallions(params.Logger, params.Registry)).Methods("GET")
 	rtr.Handle("/trips/v1/medallions/{medallions}", handler.TripsByMedallion(params.Logger, params.Svc)).Methods("GET")
-	rtr.Handle("/trips/v1/medallion/{medallion}/pickupdate/{pickupdate}", handler.TripsByMedallionsOnPickUpDate(params.Logger, params.Svc)).Methods("GET")
+	rtr.Handle("/trips/v1/medallions/{medallions}/pickups", handler.TripsByMedallionsInDateRange(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/medallions/{medallions}/histogram", handler.PickUpHistogram(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/medallions/{medallions}/metrics", handler.Metrics(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/medallion/{medallion}/trips", handler.Trips(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/medallion/{medallion}/pickups/daily", handler.TripsByMedallionPerDay(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/medallion/{medallions}/pickupdate/{pickupdate}", handler.TripsByMedallionsOnPickUpDate(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/drivers/{licenses}", handler.TripsByDriver(params.Logger, params.Svc)).Methods("GET")
+	rtr.Handle("/trips/v1/driver/{license}/pickupdate/{pickupdate}", handler.TripsByDriverOnPickUpDate(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/leaderboard", handler.TopMedallions(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/area", handler.TripsInArea(params.Logger, params.Svc, params.Bounds)).Methods("GET")
+	rtr.Handle("/trips/v1/queries", handler.TripsByQuery(params.Logger, params.Svc, params.Bounds)).Methods("POST")
+	rtr.Handle("/graphql", handler.GraphQL(params.Logger, graph.NewSchema(params.Svc, params.Bounds))).Methods("POST")
 	rtr.Handle("/trips/v1/cache/contents", handler.ClearCache(params.Logger, params.Cache)).Methods("DELETE")
+	rtr.Handle("/trips/v1/cache/stats", handler.CacheStats(params.Logger, params.Inspector)).Methods("GET")
+	rtr.Handle("/trips/v1/cache/entries", handler.Admin(params.Logger, params.AdminToken, handler.CacheEntries(params.Logger, params.Inspector))).Methods("GET")
 	rtr.Handle("/health", params.Health).Methods("GET")
+	rtr.NotFoundHandler = handler.RequestID(handler.NotFound(params.Logger))
+	rtr.MethodNotAllowedHandler = handler.RequestID(handler.MethodNotAllowed(params.Logger))
+	rtr.Use(handler.RequestID)
 	return rtr
 }
@@ -4,17 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/dimiro1/health"
 	dbhealth "github.com/dimiro1/health/db"
-	"github.com/muesli/cache2go"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
-	"github.com/nikhil-github/api-cab-data/pkg/cache"
 	"github.com/nikhil-github/api-cab-data/pkg/database"
+	"github.com/nikhil-github/api-cab-data/pkg/registry"
+	"github.com/nikhil-github/api-cab-data/pkg/rpc"
 	"github.com/nikhil-github/api-cab-data/pkg/service"
+	"github.com/nikhil-github/api-cab-data/pkg/validation"
 )
 
 // Start wires the services and start the app.
@@ -26,13 +30,38 @@ func Start(cfg *Config, logger *zap.Logger) error {
 		logger.Fatal("Failed to get database connection ", zap.Error(err))
 	}
 
-	cacheSvc := cache.New(cache2go.Cache("Cab-Trips-Data"))
-	dbSvc := database.NewQueryer(dbx, logger)
-	tripSvc := service.New(dbSvc, cacheSvc, cacheSvc, logger)
-	router := NewRouter(&Params{Health: registerHealthCheck(dbx.DB), Logger: logger, Svc: tripSvc, Cache: cacheSvc})
+	cacheSvc, err := NewCache(cfg.Cache, logger)
+	if err != nil {
+		return errors.Wrap(err, "failed to create cache")
+	}
+	logger.Info("Caching trip figures", zap.String("backend", cfg.Cache.Backend))
+	zone, err := time.LoadLocation(cfg.DB.Zone)
+	if err != nil {
+		return errors.Wrapf(err, "invalid DB zone %s", cfg.DB.Zone)
+	}
+	dbSvc := database.NewQueryer(dbx, zone, logger)
+	checkTripIndex(ctx, dbSvc, cfg.DB.CreateIndex, logger)
+
+	logger.Info("Building medallion registry")
+	medallions, err := registry.Load(ctx, dbSvc)
+	if err != nil {
+		return errors.Wrap(err, "failed to build medallion registry")
+	}
+	tripSvc := service.New(dbSvc, zone, medallions, cacheSvc, cacheSvc, logger)
+	var bounds validation.Bounds
+	if first, last := medallions.Span(); first != "" {
+		if bounds, err = validation.NewBounds(first, last); err != nil {
+			return errors.Wrap(err, "failed to bound dataset dates")
+		}
+		logger.Info("Bounding dates to the dataset", zap.String("first", first), zap.String("last", last))
+	}
+	router := NewRouter(&Params{Health: registerHealthCheck(dbx.DB), Logger: logger, Svc: tripSvc, Registry: medallions, Cache: cacheSvc, Inspector: cacheSvc, AdminToken: cfg.Admin.Token, Bounds: bounds})
+
+	grpcServer := rpc.NewGRPCServer(rpc.NewServer(logger, tripSvc, cacheSvc, bounds))
 
 	errs := make(chan error)
 	serveHTTP(cfg.HTTP.Port, logger, router, errs)
+	serveGRPC(cfg.GRPC.Port, logger, grpcServer, errs)
 
 	select {
 	case err := <-errs:
@@ -54,6 +83,22 @@ func serveHTTP(port int, logger *zap.Logger, h http.Handler, errs chan error) {
 	}()
 }
 
+func serveGRPC(port int, logger *zap.Logger, s *grpc.Server, errs chan error) {
+	addr := fmt.Sprintf(":%d", port)
+
+	go func() {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			errs <- errors.Wrapf(err, "error listening for gRPC on address %s", addr)
+			return
+		}
+		logger.Info("Listening for gRPC requests .....", zap.String("grpc.address", addr))
+		if err := s.Serve(lis); err != nil {
+			errs <- errors.Wrapf(err, "error serving gRPC on address %s", addr)
+		}
+	}()
+}
+
 // register DB health check
 func registerHealthCheck(db *sql.DB) health.Handler {
 	handler := health.NewHandler()